@@ -8,11 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 )
 
+// Error is the error the API reports for a non-2xx response, with its HTTP
+// status code.
 type Error struct {
 	Code       *string `json:"code,omitempty"`
 	Param      *string `json:"param,omitempty"`
@@ -25,6 +27,8 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// RequestError is returned for a non-2xx response whose body is not an API
+// error.
 type RequestError struct {
 	StatusCode int
 }
@@ -33,8 +37,23 @@ func (r *RequestError) Error() string {
 	return fmt.Sprintf("status code %d", r.StatusCode)
 }
 
+// Unwrap returns nil: a RequestError wraps no other error.
 func (r *RequestError) Unwrap() error {
-	return r
+	return nil
+}
+
+// statusCodeOf returns the HTTP status code of an API error, or 0 when err
+// did not come from an API response.
+func statusCodeOf(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode
+	}
+	return 0
 }
 
 type ErrorResponseBody struct {
@@ -51,6 +70,31 @@ type TokensUsage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// ResponseMeta describes how a response was obtained. It is embedded in
+// every response body and is not part of the API payload.
+type ResponseMeta struct {
+	// CacheHit reports whether the response was served from the cache.
+	CacheHit bool `json:"-"`
+	// Hedged reports whether a second request was sent by HedgeMiddleware.
+	Hedged bool `json:"-"`
+
+	raw     json.RawMessage
+	unknown map[string]json.RawMessage
+}
+
+func (m *ResponseMeta) responseMeta() *ResponseMeta {
+	return m
+}
+
+// responseMetaOf returns the ResponseMeta of a decoded result, or nil when
+// it has none.
+func responseMetaOf(result any) *ResponseMeta {
+	if r, ok := result.(interface{ responseMeta() *ResponseMeta }); ok {
+		return r.responseMeta()
+	}
+	return nil
+}
+
 const (
 	apiURLPrefix = "https://api.openai.com"
 )
@@ -58,6 +102,22 @@ const (
 type Client struct {
 	token string
 	OrgID string
+	// HTTPClient sends the requests, http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// Logger receives a summary of every call, nothing is logged when nil.
+	Logger *slog.Logger
+	// LogOptions configures levels and redaction of the logs.
+	LogOptions *LogOptions
+	// Retry retries failed requests, they are not retried when nil. It can be
+	// overridden per call with WithRetry.
+	Retry *RetryPolicy
+	// SkipValidation sends request bodies without validating them.
+	SkipValidation bool
+	// Models describes the models requests are checked against,
+	// DefaultModels when nil.
+	Models *ModelRegistry
+
+	middlewares []Middleware
 }
 
 func NewClient(token string) *Client {
@@ -74,7 +134,8 @@ func NewClientWithOrg(token, orgID string) *Client {
 func (c *Client) newRequest(ctx context.Context,
 	method string,
 	url string,
-	body any) (req *http.Request, err error) {
+	body any,
+	opts *requestOptions) (req *http.Request, err error) {
 	var (
 		data              io.Reader
 		headerAccept      = "application/json; charset=utf-8"
@@ -85,24 +146,45 @@ func (c *Client) newRequest(ctx context.Context,
 		var buf bytes.Buffer
 		if b, ok := body.(ImageEditRequestBody); ok {
 			w := multipart.NewWriter(&buf)
+			b.ExtraFields = mergeExtraFields(b.ExtraFields, opts.extraFields)
 			if err = b.WriteForm(w); err != nil {
 				return
 			}
+			if err = w.Close(); err != nil {
+				return
+			}
 			headerContentType = w.FormDataContentType()
 		} else if b, ok := body.(ImageVariationRequestBody); ok {
 			w := multipart.NewWriter(&buf)
+			b.ExtraFields = mergeExtraFields(b.ExtraFields, opts.extraFields)
+			if err = b.WriteForm(w); err != nil {
+				return
+			}
+			if err = w.Close(); err != nil {
+				return
+			}
+			headerContentType = w.FormDataContentType()
+		} else if b, ok := body.(AudioRequestBody); ok {
+			w := multipart.NewWriter(&buf)
+			b.ExtraFields = mergeExtraFields(b.ExtraFields, opts.extraFields)
 			if err = b.WriteForm(w); err != nil {
 				return
 			}
+			if err = w.Close(); err != nil {
+				return
+			}
 			headerContentType = w.FormDataContentType()
 		} else {
-			if err = json.NewEncoder(&buf).Encode(body); err != nil {
+			if err = encodeJSON(&buf, body, opts.extraFields); err != nil {
 				return
 			}
 		}
 		data = &buf
 	}
 
+	if url, err = opts.url(url); err != nil {
+		return
+	}
 	if req, err = http.NewRequestWithContext(ctx, method, url, data); err != nil {
 		return
 	}
@@ -119,15 +201,27 @@ func (c *Client) newRequest(ctx context.Context,
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
 	req.Header.Set("Accept", headerAccept)
 	req.Header.Set("Content-Type", headerContentType)
+	for key, values := range opts.header {
+		req.Header[key] = values
+	}
 
 	return
 }
 
-func (c *Client) getRequest(req *http.Request, v any) error {
-	res, err := http.DefaultClient.Do(req)
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) getRequest(call *Call) error {
+	req, v := call.Request, call.Result
+	res, err := c.httpClient().Do(req)
 	if err != nil {
 		return err
 	}
+	call.Response = res
 	var wasStreamResponse bool
 	defer func() {
 		if !wasStreamResponse {
@@ -136,14 +230,12 @@ func (c *Client) getRequest(req *http.Request, v any) error {
 	}()
 
 	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			log.Printf("%v", err)
-		} else {
-			log.Printf("%s", body)
+		var errBody ErrorResponseBody
+		if err = json.NewDecoder(res.Body).Decode(&errBody); err != nil || errBody.Error == nil {
+			return &RequestError{StatusCode: res.StatusCode}
 		}
-
-		return errors.New(res.Status)
+		errBody.Error.StatusCode = res.StatusCode
+		return errBody.Error
 	}
 
 	if v != nil {
@@ -156,12 +248,11 @@ func (c *Client) getRequest(req *http.Request, v any) error {
 				if b.StreamChan != nil {
 					wasStreamResponse = true
 
-					go func(b *ChatResponseBody, r io.ReadCloser) {
+					go func(ctx context.Context, ch chan *ChatStreamChunk, r io.ReadCloser) {
 						defer func() {
 							_ = r.Close()
-							//log.Printf("r.Closed")
+							close(ch)
 						}()
-						//body.StreamChan = make(chan *ChatStreamChunk, 128)
 						const dataField = "data: "
 						scanner := bufio.NewScanner(r)
 						for scanner.Scan() {
@@ -169,28 +260,26 @@ func (c *Client) getRequest(req *http.Request, v any) error {
 							if bytes.HasPrefix(line, []byte(dataField)) {
 								line = bytes.TrimPrefix(line, []byte(dataField))
 								if bytes.Equal(line, []byte("[DONE]")) {
-									//log.Printf("close: DONE")
-									close(b.StreamChan)
 									break
 								}
 								var chunk ChatStreamChunk
-								if err = json.Unmarshal(line, &chunk); err != nil {
-									//
-									log.Printf("unmarshal error: %v", err)
+								if err := decodeResult(line, &chunk); err != nil {
+									c.logStreamError(ctx, err)
 								} else {
-									b.StreamChan <- &chunk
+									ch <- &chunk
 								}
-								//log.Printf("data: [%s]", line)
-							} else {
-								//log.Printf("line: [%s]", line)
 							}
 						}
-					}(b, res.Body)
+					}(req.Context(), b.StreamChan, res.Body)
 				}
 				return nil
 			}
 		}
-		return json.NewDecoder(res.Body).Decode(v)
+		var data []byte
+		if data, err = io.ReadAll(res.Body); err != nil {
+			return err
+		}
+		return decodeResult(data, v)
 	}
 
 	return nil
@@ -2,50 +2,117 @@ package openai
 
 import (
 	"context"
-	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"strconv"
 )
 
 // Audio
 // Learn how to turn audio into text.
 
 type AudioRequestBody struct {
+	// File is the path of the audio file to upload, in one of the mp3, mp4,
+	// mpeg, mpga, m4a, wav or webm formats.
 	File           string  `json:"file"`
 	Model          string  `json:"model"`
 	Prompt         string  `json:"prompt,omitempty"`
 	ResponseFormat string  `json:"response_format,omitempty"`
 	Temperature    float32 `json:"temperature,omitempty"`
 	Language       string  `json:"language,omitempty"` // just create transcription
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
 }
 
-type AudioResponseBody struct {
-	Text string `json:"text"`
+// MarshalJSON implements json.Marshaler.
+func (b AudioRequestBody) MarshalJSON() ([]byte, error) {
+	type plain AudioRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
 }
 
-// CreateTranscription Transcribes audio into the input language.
-// POST https://api.openai.com/v1/audio/transcriptions
-func (c *Client) CreateTranscription(
-	ctx context.Context,
-	reqBody AudioRequestBody) (resBody AudioResponseBody, err error) {
-	if reqBody.File == "" {
-		err = errors.New("`file` not provided")
+// Validate implements Validator.
+func (b AudioRequestBody) Validate() error {
+	var v validation
+	v.required("file", b.File)
+	v.required("model", b.Model)
+	v.oneOf("response_format", b.ResponseFormat, "json", "text", "srt", "verbose_json", "vtt")
+	v.floatRange("temperature", b.Temperature, 0, 1)
+	return v.err()
+}
+
+// WriteForm writes the body as the multipart form the API expects, uploading
+// the audio file.
+func (b AudioRequestBody) WriteForm(w *multipart.Writer) (err error) {
+	fileWriter, err := w.CreateFormFile("file", b.File)
+	if err != nil {
 		return
 	}
 
-	switch reqBody.Model {
-	case Whisper1:
-	default:
-		err = errors.New("only `whisper-1` is currently available")
+	var f *os.File
+	if f, err = os.Open(b.File); err != nil {
 		return
 	}
 
-	const apiURL = apiURLPrefix + "/v1/audio/transcriptions"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
+	defer func() {
+		_ = f.Close()
+	}()
+
+	if _, err = io.Copy(fileWriter, f); err != nil {
 		return
 	}
 
-	err = c.getRequest(req, &resBody)
+	if err = writeFormField(w, b.ExtraFields, "model", b.Model); err != nil {
+		return
+	}
+
+	if b.Prompt != "" {
+		if err = writeFormField(w, b.ExtraFields, "prompt", b.Prompt); err != nil {
+			return
+		}
+	}
+
+	if b.ResponseFormat != "" {
+		if err = writeFormField(w, b.ExtraFields, "response_format", b.ResponseFormat); err != nil {
+			return
+		}
+	}
+
+	if b.Temperature != 0 {
+		if err = writeFormField(w, b.ExtraFields, "temperature", strconv.FormatFloat(float64(b.Temperature), 'f', -1, 32)); err != nil {
+			return
+		}
+	}
+
+	if b.Language != "" {
+		if err = writeFormField(w, b.ExtraFields, "language", b.Language); err != nil {
+			return
+		}
+	}
+
+	err = writeExtraFields(w, b.ExtraFields)
+	return
+}
+
+type AudioResponseBody struct {
+	Text string `json:"text"`
+	// Language and Duration, in seconds, are returned for the verbose_json
+	// response format.
+	Language string  `json:"language,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+
+	ResponseMeta
+}
+
+// CreateTranscription Transcribes audio into the input language.
+// POST https://api.openai.com/v1/audio/transcriptions
+func (c *Client) CreateTranscription(
+	ctx context.Context,
+	reqBody AudioRequestBody,
+	opts ...RequestOption) (resBody AudioResponseBody, err error) {
+	const apiURL = apiURLPrefix + "/v1/audio/transcriptions"
+	err = c.do(ctx, "CreateTranscription", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 
 	return
 }
@@ -54,26 +121,10 @@ func (c *Client) CreateTranscription(
 // POST https://api.openai.com/v1/audio/translations
 func (c *Client) CreateTranslation(
 	ctx context.Context,
-	reqBody AudioRequestBody) (resBody AudioResponseBody, err error) {
-	if reqBody.File == "" {
-		err = errors.New("")
-		return
-	}
-
-	switch reqBody.Model {
-	case Whisper1:
-	default:
-		err = errors.New("only `whisper-1` is currently available")
-		return
-	}
-
+	reqBody AudioRequestBody,
+	opts ...RequestOption) (resBody AudioResponseBody, err error) {
 	const apiURL = apiURLPrefix + "/v1/audio/translations"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateTranslation", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 
 	return
 }
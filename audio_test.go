@@ -1 +1,71 @@
 package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestClient_CreateTranscription(t *testing.T) {
+	c := newCassetteClient(t, "create_transcription")
+	body, err := c.CreateTranscription(context.Background(), AudioRequestBody{
+		File:           "testdata/silence.wav",
+		Model:          Whisper1,
+		ResponseFormat: "verbose_json",
+	})
+	if err != nil {
+		t.Fatalf("Create transcription error: %v", err)
+	}
+	if body.Duration == 0 {
+		t.Errorf("Create transcription = %+v", body)
+	}
+}
+
+func TestClient_CreateTranslation(t *testing.T) {
+	c := newCassetteClient(t, "create_translation")
+	if _, err := c.CreateTranslation(context.Background(), AudioRequestBody{
+		File:  "testdata/silence.wav",
+		Model: Whisper1,
+	}); err != nil {
+		t.Fatalf("Create translation error: %v", err)
+	}
+}
+
+func TestAudioRequestBody_form(t *testing.T) {
+	wav, err := os.ReadFile("testdata/silence.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("parse form error: %v", err)
+		}
+		form := r.MultipartForm.Value
+		if form["model"][0] != Whisper1 || form["language"][0] != "en" ||
+			form["temperature"][0] != "0.2" || len(form["prompt"]) != 0 {
+			t.Errorf("form = %v", form)
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("form file error: %v", err)
+		}
+		data, _ := io.ReadAll(f)
+		if header.Filename != "silence.wav" || !bytes.Equal(data, wav) {
+			t.Errorf("file %s of %d bytes, want silence.wav of %d", header.Filename, len(data), len(wav))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"text":""}`))
+	})
+	_, err = c.CreateTranscription(context.Background(), AudioRequestBody{
+		File:        "testdata/silence.wav",
+		Model:       Whisper1,
+		Temperature: 0.2,
+		Language:    "en",
+	})
+	if err != nil {
+		t.Fatalf("create transcription error: %v", err)
+	}
+}
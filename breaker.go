@@ -0,0 +1,249 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is matched by the errors returned for calls rejected by an
+// open circuit breaker.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitOpenError is returned for calls rejected by an open circuit.
+type CircuitOpenError struct {
+	Key CircuitKey
+	// RetryAfter is how long the circuit stays open.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit open for %s, retry after %s", e.Key, e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) report true.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every call until the cool-down elapsed.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of trial calls through, closing
+	// the circuit when they succeed and opening it again when one fails.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitKey identifies a circuit. Every operation and model pair has its own.
+type CircuitKey struct {
+	Operation string
+	Model     string
+}
+
+func (k CircuitKey) String() string {
+	if k.Model == "" {
+		return k.Operation
+	}
+	return k.Operation + " " + k.Model
+}
+
+// CircuitBreakerOptions configures a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures that opens a
+	// circuit, 5 when zero.
+	FailureThreshold int
+	// CoolDown is how long a circuit stays open before trial calls are let
+	// through, 30 seconds when zero.
+	CoolDown time.Duration
+	// HalfOpenCalls is the number of concurrent trial calls let through by a
+	// half-open circuit, 1 when zero.
+	HalfOpenCalls int
+	// IsFailure reports whether an error counts as a failure. By default rate
+	// limits, server errors, timeouts and network errors do; other API errors
+	// and canceled calls do not.
+	IsFailure func(err error) bool
+	// OnStateChange is called when a circuit changes state. It must not block.
+	OnStateChange func(key CircuitKey, from, to CircuitState)
+}
+
+// CircuitBreaker stops sending calls to an operation and model that keep
+// failing, so a degraded API does not pile up waiting requests.
+type CircuitBreaker struct {
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	circuits map[CircuitKey]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trials   int
+	// generation counts the state changes, so that a call finishing after
+	// one is not counted for the new state.
+	generation int
+}
+
+// admission is how a circuit let a call through.
+type admission struct {
+	generation int
+	trial      bool
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with all circuits closed.
+func NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.CoolDown <= 0 {
+		opts.CoolDown = 30 * time.Second
+	}
+	if opts.HalfOpenCalls <= 0 {
+		opts.HalfOpenCalls = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = isFailure
+	}
+	return &CircuitBreaker{
+		opts:     opts,
+		now:      time.Now,
+		circuits: make(map[CircuitKey]*circuit),
+	}
+}
+
+// State returns the state of the circuit of an operation and model.
+func (b *CircuitBreaker) State(key CircuitKey) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[key]; ok {
+		if c.state == CircuitOpen && b.now().Sub(c.openedAt) >= b.opts.CoolDown {
+			return CircuitHalfOpen
+		}
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// Middleware returns the Middleware applying the breaker to a Client.
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) error {
+			key := CircuitKey{Operation: call.Operation, Model: call.Model()}
+			a, err := b.allow(key)
+			if err != nil {
+				return err
+			}
+			err = next(call)
+			b.record(key, a, err)
+			return err
+		}
+	}
+}
+
+func (b *CircuitBreaker) allow(key CircuitKey) (admission, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	switch c.state {
+	case CircuitOpen:
+		if elapsed := b.now().Sub(c.openedAt); elapsed < b.opts.CoolDown {
+			return admission{}, &CircuitOpenError{Key: key, RetryAfter: b.opts.CoolDown - elapsed}
+		}
+		b.setState(key, c, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if c.trials >= b.opts.HalfOpenCalls {
+			return admission{}, &CircuitOpenError{Key: key}
+		}
+		c.trials++
+		return admission{generation: c.generation, trial: true}, nil
+	}
+	return admission{generation: c.generation}, nil
+}
+
+func (b *CircuitBreaker) record(key CircuitKey, a admission, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[key]
+	if a.generation != c.generation {
+		// The circuit changed state since the call was let through.
+		return
+	}
+	failed := err != nil && b.opts.IsFailure(err)
+	if a.trial {
+		c.trials--
+		switch {
+		case failed:
+			b.open(key, c)
+		case err == nil:
+			c.failures = 0
+			b.setState(key, c, CircuitClosed)
+		}
+		// Other errors neither prove nor disprove the API is healthy.
+		return
+	}
+	if failed {
+		c.failures++
+		if c.failures >= b.opts.FailureThreshold {
+			b.open(key, c)
+		}
+	} else {
+		c.failures = 0
+	}
+}
+
+func (b *CircuitBreaker) open(key CircuitKey, c *circuit) {
+	c.openedAt = b.now()
+	c.failures = 0
+	c.trials = 0
+	b.setState(key, c, CircuitOpen)
+}
+
+func (b *CircuitBreaker) setState(key CircuitKey, c *circuit, state CircuitState) {
+	from := c.state
+	if from == state {
+		return
+	}
+	c.state = state
+	c.generation++
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(key, from, state)
+	}
+}
+
+// isFailure is the default CircuitBreakerOptions.IsFailure.
+func isFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if status := statusCodeOf(err); status != 0 {
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+	return true
+}
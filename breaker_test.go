@@ -0,0 +1,129 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(`{"object":"list"}`))
+	})
+
+	type change struct{ from, to CircuitState }
+	var changes []change
+	b := NewCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 2,
+		CoolDown:         time.Minute,
+		OnStateChange: func(key CircuitKey, from, to CircuitState) {
+			changes = append(changes, change{from, to})
+		},
+	})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	c.Use(b.Middleware())
+	ctx := context.Background()
+	key := CircuitKey{Operation: "ListModels"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ListModels(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d error = %v, want a server error", i, err)
+		}
+	}
+	if got := b.State(key); got != CircuitOpen {
+		t.Fatalf("state = %v, want open", got)
+	}
+	_, err := c.ListModels(ctx)
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.RetryAfter != time.Minute {
+		t.Errorf("error = %v, want circuit open for a minute", err)
+	}
+	if got := b.State(CircuitKey{Operation: "RetrieveModel"}); got != CircuitClosed {
+		t.Errorf("other operation state = %v, want closed", got)
+	}
+
+	// A failed trial opens the circuit again, a successful one closes it.
+	now = now.Add(time.Minute)
+	if _, err = c.ListModels(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("trial error = %v, want a server error", err)
+	}
+	now = now.Add(time.Minute)
+	status.Store(http.StatusOK)
+	if _, err = c.ListModels(ctx); err != nil {
+		t.Errorf("trial error = %v", err)
+	}
+	if got := b.State(key); got != CircuitClosed {
+		t.Errorf("state = %v, want closed", got)
+	}
+
+	want := []change{
+		{CircuitClosed, CircuitOpen},
+		{CircuitOpen, CircuitHalfOpen},
+		{CircuitHalfOpen, CircuitOpen},
+		{CircuitOpen, CircuitHalfOpen},
+		{CircuitHalfOpen, CircuitClosed},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("state changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBreaker_ClientErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad request","type":"invalid_request_error"}}`))
+	})
+	b := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1})
+	c.Use(b.Middleware())
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.ListFiles(context.Background()); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: client errors opened the circuit", i)
+		}
+	}
+}
+
+func TestCircuitBreaker_trials(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1, CoolDown: time.Minute})
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	key := CircuitKey{Operation: "ListModels"}
+	serverErr := &Error{StatusCode: http.StatusInternalServerError}
+
+	// A call let through by the closed circuit is still running when the
+	// circuit opens, and finishes once it is half-open.
+	slow, err := b.allow(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing, _ := b.allow(key)
+	b.record(key, failing, serverErr)
+	now = now.Add(time.Minute)
+	trial, err := b.allow(key)
+	if err != nil {
+		t.Fatalf("trial refused: %v", err)
+	}
+	b.record(key, slow, nil)
+	if got := b.State(key); got != CircuitHalfOpen {
+		t.Errorf("state after a call that is not a trial = %v, want half-open", got)
+	}
+	if _, err = b.allow(key); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second trial error = %v, want circuit open", err)
+	}
+	b.record(key, trial, nil)
+	if got := b.State(key); got != CircuitClosed {
+		t.Errorf("state after the trial = %v, want closed", got)
+	}
+}
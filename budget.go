@@ -0,0 +1,387 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is matched by the errors returned for calls refused by a
+// Budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// BudgetExceededError is returned for a call that could take a budget limit
+// over its hard limit.
+type BudgetExceededError struct {
+	Limit BudgetLimit
+	// Labels are those of the limit's group the call belongs to.
+	Labels Labels
+	// Spent is the amount spent in the current period, including the
+	// estimates of calls in flight.
+	Spent float64
+	// Estimate is the worst-case amount of the call.
+	Estimate float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget %s exceeded: %g of %g %s spent this %s, call may take %g",
+		e.Limit.Name, e.Spent, e.Limit.Hard, e.Limit.Unit, e.Limit.Period, e.Estimate)
+}
+
+// Is makes errors.Is(err, ErrBudgetExceeded) report true.
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// BudgetPeriod is the period after which the spending of a limit restarts
+// from zero.
+type BudgetPeriod int
+
+const (
+	BudgetDaily BudgetPeriod = iota
+	BudgetMonthly
+)
+
+func (p BudgetPeriod) String() string {
+	switch p {
+	case BudgetDaily:
+		return "day"
+	case BudgetMonthly:
+		return "month"
+	}
+	return fmt.Sprintf("BudgetPeriod(%d)", int(p))
+}
+
+// BudgetUnit is what a limit counts.
+type BudgetUnit int
+
+const (
+	// BudgetDollars counts the cost in US dollars.
+	BudgetDollars BudgetUnit = iota
+	// BudgetTokens counts the prompt and completion tokens.
+	BudgetTokens
+)
+
+func (u BudgetUnit) String() string {
+	switch u {
+	case BudgetDollars:
+		return "dollars"
+	case BudgetTokens:
+		return "tokens"
+	}
+	return fmt.Sprintf("BudgetUnit(%d)", int(u))
+}
+
+// LabelField selects fields of Labels.
+type LabelField uint8
+
+const (
+	LabelTenant LabelField = 1 << iota
+	LabelFeature
+	LabelUser
+)
+
+// BudgetLimit limits the spending of the calls matching its labels.
+type BudgetLimit struct {
+	Name string
+	// Match selects the calls the limit applies to. Empty fields match any
+	// value.
+	Match Labels
+	// Per gives every value of the selected label fields its own budget,
+	// e.g. LabelTenant limits the spending of each tenant. The calls the
+	// limit applies to share a budget when zero.
+	Per    LabelField
+	Period BudgetPeriod
+	Unit   BudgetUnit
+	// Hard refuses calls that could take the spending over it, none when
+	// zero.
+	Hard float64
+	// Soft calls BudgetOptions.OnSoftLimit the first time the spending of a
+	// period reaches it, none when zero.
+	Soft float64
+}
+
+func (l *BudgetLimit) matches(labels Labels) bool {
+	return (l.Match.Tenant == "" || l.Match.Tenant == labels.Tenant) &&
+		(l.Match.Feature == "" || l.Match.Feature == labels.Feature) &&
+		(l.Match.User == "" || l.Match.User == labels.User)
+}
+
+// group returns the labels identifying the budget of a call.
+func (l *BudgetLimit) group(labels Labels) Labels {
+	var g Labels
+	if l.Per&LabelTenant != 0 {
+		g.Tenant = labels.Tenant
+	}
+	if l.Per&LabelFeature != 0 {
+		g.Feature = labels.Feature
+	}
+	if l.Per&LabelUser != 0 {
+		g.User = labels.User
+	}
+	return g
+}
+
+func (l *BudgetLimit) amount(r UsageRecord) float64 {
+	if l.Unit == BudgetTokens {
+		return float64(r.PromptTokens + r.CompletionTokens)
+	}
+	return r.Cost
+}
+
+// BudgetOptions configures a Budget.
+type BudgetOptions struct {
+	Limits []BudgetLimit
+	Prices PriceTable
+	// OnSoftLimit is called when the spending of a limit's group reaches its
+	// soft limit, once per period. It must not block.
+	OnSoftLimit func(limit BudgetLimit, labels Labels, spent float64)
+	// Location is the time zone of the periods' boundaries, UTC when nil.
+	Location *time.Location
+}
+
+// Budget refuses calls once their spending limits are exhausted. Before a
+// call is sent, its worst-case usage is estimated from its prompt and
+// MaxTokens and reserved against the limits it is subject to; the reservation
+// is replaced by the actual usage once the response, or the end of its
+// stream, is received.
+type Budget struct {
+	opts BudgetOptions
+	now  func() time.Time
+
+	mu     sync.Mutex
+	spends map[budgetKey]*budgetSpend
+}
+
+type budgetKey struct {
+	limit  int
+	labels Labels
+}
+
+type budgetSpend struct {
+	period   time.Time
+	spent    float64
+	reserved float64
+	alerted  bool
+}
+
+// reservation is the estimated amount reserved for a call in a group.
+type reservation struct {
+	key    budgetKey
+	amount float64
+}
+
+// NewBudget returns a Budget with nothing spent.
+func NewBudget(opts BudgetOptions) *Budget {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	return &Budget{opts: opts, now: time.Now, spends: make(map[budgetKey]*budgetSpend)}
+}
+
+// Spent returns the amount spent in the current period by the group of a
+// limit the labels belong to.
+func (b *Budget) Spent(limit string, labels Labels) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := range b.opts.Limits {
+		if l := &b.opts.Limits[i]; l.Name == limit {
+			return b.spend(budgetKey{i, l.group(labels)}).spent
+		}
+	}
+	return 0
+}
+
+// Record adds usage made outside the Client, e.g. by another process, to
+// the spending of the limits it is subject to.
+func (b *Budget) Record(r UsageRecord) {
+	b.settle(nil, r)
+}
+
+// Middleware returns the Middleware enforcing the budget on a Client.
+func (b *Budget) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) error {
+			labels := LabelsFrom(call.Context())
+			estimate := b.estimate(call)
+			reserved, err := b.reserve(labels, estimate)
+			if err != nil {
+				return err
+			}
+			if err = next(call); err != nil {
+				b.release(reserved)
+				return err
+			}
+			settle := func(r UsageRecord) {
+				if r.Estimated {
+					// The API did not report the usage of the stream, which
+					// may have taken all that was reserved.
+					r.PromptTokens = max(r.PromptTokens, estimate.PromptTokens)
+					r.CompletionTokens = max(r.CompletionTokens, estimate.CompletionTokens)
+				}
+				b.settle(reserved, r)
+			}
+			if !observeUsage(call, settle) {
+				b.release(reserved)
+			}
+			return nil
+		}
+	}
+}
+
+// spend returns the spending of a group in the current period.
+func (b *Budget) spend(key budgetKey) *budgetSpend {
+	now := b.now().In(b.opts.Location)
+	var period time.Time
+	if b.opts.Limits[key.limit].Period == BudgetMonthly {
+		period = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, b.opts.Location)
+	} else {
+		period = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, b.opts.Location)
+	}
+	s, ok := b.spends[key]
+	if !ok || !s.period.Equal(period) {
+		// In-flight reservations carry over to the new period.
+		reserved := 0.0
+		if ok {
+			reserved = s.reserved
+		}
+		s = &budgetSpend{period: period, reserved: reserved}
+		b.spends[key] = s
+	}
+	return s
+}
+
+// reserve reserves the estimate of a call against its limits, or returns a
+// *BudgetExceededError when it could exceed one.
+func (b *Budget) reserve(labels Labels, estimate UsageRecord) ([]reservation, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var reserved []reservation
+	for i := range b.opts.Limits {
+		l := &b.opts.Limits[i]
+		if !l.matches(labels) {
+			continue
+		}
+		key := budgetKey{i, l.group(labels)}
+		s, amount := b.spend(key), l.amount(estimate)
+		if l.Hard > 0 && s.spent+s.reserved+amount > l.Hard {
+			for _, r := range reserved {
+				b.spend(r.key).reserved -= r.amount
+			}
+			return nil, &BudgetExceededError{Limit: *l, Labels: key.labels, Spent: s.spent + s.reserved, Estimate: amount}
+		}
+		s.reserved += amount
+		reserved = append(reserved, reservation{key, amount})
+	}
+	return reserved, nil
+}
+
+// release releases the reservations of a call that used nothing.
+func (b *Budget) release(reserved []reservation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, res := range reserved {
+		b.spend(res.key).reserved -= res.amount
+	}
+}
+
+// settle releases the reservations of a call and adds its actual usage.
+func (b *Budget) settle(reserved []reservation, r UsageRecord) {
+	r.Cost = b.opts.Prices.Cost(r)
+	type alert struct {
+		limit  BudgetLimit
+		labels Labels
+		spent  float64
+	}
+	var alerts []alert
+	b.mu.Lock()
+	for _, res := range reserved {
+		b.spend(res.key).reserved -= res.amount
+	}
+	for i := range b.opts.Limits {
+		l := &b.opts.Limits[i]
+		if !l.matches(r.Labels) {
+			continue
+		}
+		key := budgetKey{i, l.group(r.Labels)}
+		s := b.spend(key)
+		s.spent += l.amount(r)
+		if l.Soft > 0 && s.spent >= l.Soft && !s.alerted {
+			s.alerted = true
+			alerts = append(alerts, alert{*l, key.labels, s.spent})
+		}
+	}
+	b.mu.Unlock()
+	if b.opts.OnSoftLimit != nil {
+		for _, a := range alerts {
+			b.opts.OnSoftLimit(a.limit, a.labels, a.spent)
+		}
+	}
+}
+
+// estimate returns the worst-case usage of a call: its prompt tokens and the
+// most tokens it may generate, or the images it requests.
+func (b *Budget) estimate(call *Call) UsageRecord {
+	r := UsageRecord{
+		Labels:    LabelsFrom(call.Context()),
+		Operation: call.Operation,
+		Model:     call.Model(),
+	}
+	counter := counterFor(r.Model)
+	switch body := call.Body.(type) {
+	case ChatRequestBody:
+		prompt, err := countPromptTokens(counter, body)
+		if err != nil {
+			prompt, _ = countPromptTokens(byteCounter{}, body)
+		}
+		r.PromptTokens = prompt
+		r.CompletionTokens = max(body.N, 1) * b.maxCompletionTokens(body.Model, body.MaxTokens, prompt)
+	case CompletionRequestBody:
+		r.PromptTokens = counter.Count(body.Prompt) + counter.Count(body.Suffix)
+		maxTokens := body.MaxTokens
+		if maxTokens == 0 {
+			// The default of the completions endpoint.
+			maxTokens = 16
+		}
+		r.CompletionTokens = max(body.N, body.BestOf, 1) * maxTokens
+	case EditRequestBody:
+		r.PromptTokens = counter.Count(body.Input) + counter.Count(body.Instruction)
+		r.CompletionTokens = max(body.N, 1) * b.maxCompletionTokens(body.Model, 0, r.PromptTokens)
+	case EmbeddingsRequestBody:
+		r.PromptTokens = counter.Count(body.Input)
+	case ImageRequestBody:
+		r.Images, r.ImageSize = max(body.N, 1), body.Size
+	case ImageEditRequestBody:
+		r.Images, r.ImageSize = max(body.N, 1), body.Size
+	case ImageVariationRequestBody:
+		r.Images, r.ImageSize = max(body.N, 1), body.Size
+	}
+	r.Cost = b.opts.Prices.Cost(r)
+	return r
+}
+
+// unknownCompletionTokens is reserved for a completion without max_tokens of
+// a model whose context window is unknown, the most any known model outputs.
+const unknownCompletionTokens = 16384
+
+// maxCompletionTokens returns the most tokens a completion may take: its
+// max_tokens, or else what the context window of the model leaves, or else
+// unknownCompletionTokens.
+func (b *Budget) maxCompletionTokens(model string, maxTokens, prompt int) int {
+	if maxTokens > 0 {
+		return maxTokens
+	}
+	models := b.opts.Prices.Models
+	if models == nil {
+		models = DefaultModels
+	}
+	info, ok := models.Lookup(model)
+	if info.MaxOutputTokens > 0 {
+		return info.MaxOutputTokens
+	}
+	if !ok || info.ContextWindow == 0 {
+		return unknownCompletionTokens
+	}
+	return max(info.ContextWindow-prompt, 0)
+}
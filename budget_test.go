@@ -0,0 +1,146 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	c := newTestClient(t, usageTestServer)
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	var alerts []Labels
+	budget := NewBudget(BudgetOptions{
+		Limits: []BudgetLimit{
+			{Name: "tenant", Per: LabelTenant, Period: BudgetDaily, Hard: 10, Soft: 5},
+			{Name: "search", Match: Labels{Feature: "search"}, Period: BudgetMonthly, Unit: BudgetTokens, Hard: 100},
+		},
+		Prices: PriceTable{Overrides: map[string]Pricing{"custom": {Input: 1, Output: 2}}},
+		OnSoftLimit: func(limit BudgetLimit, labels Labels, spent float64) {
+			if limit.Name != "tenant" || spent != 6 {
+				t.Errorf("OnSoftLimit(%s, %+v, %g)", limit.Name, labels, spent)
+			}
+			alerts = append(alerts, labels)
+		},
+	})
+	budget.now = func() time.Time { return now }
+	c.Use(budget.Middleware())
+
+	// Each call costs $2, the server reporting 1M prompt and 500k completion
+	// tokens; its worst case is $1 for the 500k tokens it may generate.
+	chat := func(tenant string) error {
+		ctx := WithLabels(context.Background(), Labels{Tenant: tenant, Feature: "chat"})
+		_, err := c.CreateChatCompletion(ctx, ChatRequestBody{
+			Model:     "custom",
+			Messages:  []*ChatMessage{{Role: RoleUser, Content: "hi"}},
+			MaxTokens: 500000,
+		})
+		return err
+	}
+	for i := 0; i < 5; i++ {
+		if err := chat("acme"); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	err := chat("acme")
+	var exceeded *BudgetExceededError
+	if !errors.Is(err, ErrBudgetExceeded) || !errors.As(err, &exceeded) ||
+		exceeded.Limit.Name != "tenant" || exceeded.Spent != 10 || exceeded.Labels.Tenant != "acme" {
+		t.Fatalf("sixth call error = %v, want the tenant budget exceeded", err)
+	}
+	if err = chat("globex"); err != nil {
+		t.Errorf("other tenant refused: %v", err)
+	}
+	if got := budget.Spent("tenant", Labels{Tenant: "acme"}); got != 10 {
+		t.Errorf("Spent() = %g, want 10", got)
+	}
+	if len(alerts) != 1 || alerts[0] != (Labels{Tenant: "acme"}) {
+		t.Errorf("alerts = %+v", alerts)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if err = chat("acme"); err != nil {
+		t.Errorf("call on the next day refused: %v", err)
+	}
+
+	// The worst case of a search call exceeds its token budget.
+	ctx := WithLabels(context.Background(), Labels{Tenant: "acme", Feature: "search"})
+	_, err = c.CreateChatCompletion(ctx, ChatRequestBody{
+		Model:     GPT4o,
+		Messages:  []*ChatMessage{{Role: RoleUser, Content: "hi"}},
+		MaxTokens: 200,
+	})
+	if !errors.As(err, &exceeded) || exceeded.Limit.Name != "search" || exceeded.Estimate < 200 {
+		t.Errorf("search call error = %v", err)
+	}
+	if got := budget.Spent("tenant", Labels{Tenant: "acme"}); got != 2 {
+		t.Errorf("refused call spent %g", got-2)
+	}
+
+	budget.Record(UsageRecord{Labels: Labels{Feature: "search"}, PromptTokens: 60})
+	if got := budget.Spent("search", Labels{}); got != 60 {
+		t.Errorf("Spent(search) = %g, want 60", got)
+	}
+}
+
+func TestBudget_maxCompletionTokens(t *testing.T) {
+	b := NewBudget(BudgetOptions{})
+	tests := []struct {
+		model             string
+		maxTokens, prompt int
+		want              int
+	}{
+		{GPT4, 100, 1000, 100},
+		{GPT4, 0, 1000, 8192 - 1000},
+		{GPT4o, 0, 1000, 16384},
+		{TextDavinciEdit001, 0, 1000, unknownCompletionTokens},
+		{"custom", 0, 1000, unknownCompletionTokens},
+	}
+	for _, tt := range tests {
+		if got := b.maxCompletionTokens(tt.model, tt.maxTokens, tt.prompt); got != tt.want {
+			t.Errorf("maxCompletionTokens(%s, %d, %d) = %d, want %d", tt.model, tt.maxTokens, tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestBudget_stream(t *testing.T) {
+	c := newTestClient(t, usageTestServer)
+	budget := NewBudget(BudgetOptions{
+		Limits: []BudgetLimit{{Name: "tokens", Period: BudgetDaily, Unit: BudgetTokens, Hard: 1000}},
+	})
+	c.Use(budget.Middleware())
+
+	tests := []struct {
+		options *StreamOptions
+		want    float64
+	}{
+		// 7 prompt and 1 completion tokens, as reported.
+		{&StreamOptions{IncludeUsage: true}, 8},
+		// Unreported: the 12 bytes of the prompt and the 50 tokens reserved.
+		{nil, 62},
+	}
+	for _, tt := range tests {
+		spent := budget.Spent("tokens", Labels{})
+		res, err := c.CreateChatCompletion(context.Background(), ChatRequestBody{
+			Model:         "custom",
+			Messages:      []*ChatMessage{{Role: RoleUser, Content: "hi"}},
+			MaxTokens:     50,
+			Stream:        true,
+			StreamOptions: tt.options,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range res.StreamChan {
+		}
+		// The stream is settled once it is closed, after the channel drained.
+		deadline := time.Now().Add(time.Second)
+		for budget.Spent("tokens", Labels{})-spent != tt.want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := budget.Spent("tokens", Labels{}) - spent; got != tt.want {
+			t.Errorf("IncludeUsage %v: spent %g, want %g", tt.options != nil, got, tt.want)
+		}
+	}
+}
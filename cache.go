@@ -0,0 +1,241 @@
+package openai
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CacheStore stores cached responses by key.
+type CacheStore interface {
+	// Get returns the value stored for key, reporting false when there is
+	// none or it expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for key. A zero ttl keeps it until it is evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CacheOptions configures CacheMiddleware.
+type CacheOptions struct {
+	// Store holds the cached responses.
+	Store CacheStore
+	// TTL is how long a response stays cached, until eviction when zero.
+	TTL time.Duration
+	// AllowNonDeterministic also caches requests whose response may differ
+	// from one call to the next, such as chat completions with a non-zero
+	// temperature, image generations, and file and model listings.
+	AllowNonDeterministic bool
+}
+
+// CacheMiddleware returns a Middleware that serves repeated identical
+// requests from a cache. Requests are identified by endpoint and normalized
+// body. Only deterministic requests are cached unless AllowNonDeterministic
+// is set: embeddings, moderations, and chat completions, completions and
+// edits sent with a temperature of 0 and a single choice. A zero Temperature
+// is not sent, so the temperature must be set with ExtraFields:
+//
+//	openai.ChatRequestBody{..., ExtraFields: map[string]any{"temperature": 0}}
+//
+// Streams and requests that modify files are never cached. Responses served
+// from the cache have CacheHit set.
+func CacheMiddleware(opts CacheOptions) Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) error {
+			if !cacheable(call, opts.AllowNonDeterministic) {
+				return next(call)
+			}
+			ctx := call.Context()
+			key, err := cacheKey(call)
+			if err != nil {
+				return next(call)
+			}
+			if data, ok, err := opts.Store.Get(ctx, key); err == nil && ok {
+				if err = decodeResult(data, call.Result); err == nil {
+					if meta := responseMetaOf(call.Result); meta != nil {
+						meta.CacheHit = true
+					}
+					return nil
+				}
+			}
+
+			if err = next(call); err != nil {
+				return err
+			}
+			if data, err := encodeResult(call.Result); err == nil {
+				_ = opts.Store.Set(ctx, key, data, opts.TTL)
+			}
+			return nil
+		}
+	}
+}
+
+// cacheable reports whether the response of a call may be cached.
+func cacheable(call *Call, allowNonDeterministic bool) bool {
+	if call.Stream() || call.Result == nil {
+		return false
+	}
+	switch call.Operation {
+	case "UploadFile", "DeleteFile":
+		return false
+	}
+	return allowNonDeterministic || deterministic(call)
+}
+
+// deterministic reports whether identical calls are expected to produce the
+// same response.
+func deterministic(call *Call) bool {
+	switch call.Body.(type) {
+	case EmbeddingsRequestBody, ModerationRequestBody:
+		return true
+	case ChatRequestBody, CompletionRequestBody, EditRequestBody:
+	default:
+		return false
+	}
+	fields, err := sentFields(call)
+	if err != nil {
+		return false
+	}
+	// A zero Temperature is omitted, and the API then samples at its default
+	// of 1: only a temperature of 0 that is sent makes the call deterministic.
+	temperature, ok := fields["temperature"].(json.Number)
+	if !ok {
+		return false
+	}
+	if v, err := temperature.Float64(); err != nil || v != 0 {
+		return false
+	}
+	for _, name := range []string{"n", "best_of"} {
+		if n, ok := fields[name].(json.Number); ok {
+			if v, err := n.Float64(); err != nil || v > 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sentFields returns the fields of the body sent for a call, its extra
+// fields merged, numbers decoded as json.Number.
+func sentFields(call *Call) (map[string]any, error) {
+	var extraFields map[string]any
+	if call.options != nil {
+		extraFields = call.options.extraFields
+	}
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, call.Body, extraFields); err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(&buf)
+	d.UseNumber()
+	var fields map[string]any
+	if err := d.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// cacheKey hashes the endpoint URL, the credentials and the normalized
+// request body, including the extra fields of the call, so that callers with
+// different API keys or organizations do not share responses.
+func cacheKey(call *Call) (string, error) {
+	fields, err := sentFields(call)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(call.Request.Method + " " + call.Request.URL.String() + "\n"))
+	for _, name := range []string{"Authorization", "OpenAI-Organization"} {
+		h.Write([]byte(call.Request.Header.Get(name) + "\n"))
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// MemoryCache is an in-memory least recently used CacheStore.
+type MemoryCache struct {
+	maxEntries int
+	maxBytes   int
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache holding at most maxEntries responses
+// of at most maxBytes in total. A zero limit is no limit.
+func NewMemoryCache(maxEntries, maxBytes int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get implements CacheStore.
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := e.Value.(*memoryEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		m.remove(e)
+		return nil, false, nil
+	}
+	m.lru.MoveToFront(e)
+	return entry.value, true, nil
+}
+
+// Set implements CacheStore.
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; ok {
+		m.remove(e)
+	}
+	if m.maxBytes > 0 && len(value) > m.maxBytes {
+		return nil
+	}
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	m.entries[key] = m.lru.PushFront(entry)
+	m.size += len(value)
+	for (m.maxEntries > 0 && m.lru.Len() > m.maxEntries) || (m.maxBytes > 0 && m.size > m.maxBytes) {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+// Len returns the number of cached responses.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+func (m *MemoryCache) remove(e *list.Element) {
+	entry := m.lru.Remove(e).(*memoryEntry)
+	delete(m.entries, entry.key)
+	m.size -= len(entry.value)
+}
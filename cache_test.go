@@ -0,0 +1,160 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	var hits atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"4"}}],
+			"data":[{"object":"embedding","embedding":[0.5,0.25]}]}`))
+	})
+	store := NewMemoryCache(10, 0)
+	c.Use(CacheMiddleware(CacheOptions{Store: store, TTL: time.Minute}))
+	ctx := context.Background()
+
+	embed := func(input string) EmbeddingsResponseBody {
+		res, err := c.CreateEmbeddings(ctx, EmbeddingsRequestBody{Model: TextEmbeddingAda002, Input: input})
+		if err != nil {
+			t.Fatalf("create embeddings error: %v", err)
+		}
+		return res
+	}
+	if res := embed("hello"); res.CacheHit {
+		t.Error("first call was a cache hit")
+	}
+	res := embed("hello")
+	if !res.CacheHit || len(res.Data) != 1 || res.Data[0].Embedding[1] != 0.25 {
+		t.Errorf("second call = %+v, want the cached response", res)
+	}
+	embed("world")
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hit %d times, want 2", got)
+	}
+
+	chat := func(temperature any) *ChatResponseBody {
+		body := ChatRequestBody{
+			Model:    GPT35Turbo,
+			Messages: []*ChatMessage{{Role: RoleUser, Content: "2+2?"}},
+		}
+		if temperature != nil {
+			body.ExtraFields = map[string]any{"temperature": temperature}
+		}
+		res, err := c.CreateChatCompletion(ctx, body)
+		if err != nil {
+			t.Fatalf("create chat completion error: %v", err)
+		}
+		return res
+	}
+	chat(0)
+	if !chat(0).CacheHit {
+		t.Error("deterministic chat completion was not cached")
+	}
+	chat(0.7)
+	if chat(0.7).CacheHit {
+		t.Error("non-deterministic chat completion was cached")
+	}
+	// Without a temperature the API samples at its default of 1.
+	chat(nil)
+	if chat(nil).CacheHit {
+		t.Error("chat completion at the default temperature was cached")
+	}
+	if got := hits.Load(); got != 7 {
+		t.Errorf("server hit %d times, want 7", got)
+	}
+
+	// Another organization does not share the cached responses.
+	c.OrgID = "org-other"
+	if chat(0).CacheHit {
+		t.Error("response cached for another organization was served")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryCache(2, 0)
+	_ = m.Set(ctx, "a", []byte("1"), 0)
+	_ = m.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = m.Get(ctx, "a")
+	_ = m.Set(ctx, "c", []byte("3"), 0)
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if v, ok, _ := m.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("Get(a) = %q, %v", v, ok)
+	}
+
+	_ = m.Set(ctx, "d", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := m.Get(ctx, "d"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	m = NewMemoryCache(0, 4)
+	_ = m.Set(ctx, "a", []byte("123"), 0)
+	_ = m.Set(ctx, "b", []byte("45"), 0)
+	if m.Len() != 1 {
+		t.Errorf("Len = %d, want 1 within the size limit", m.Len())
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	// Files the cache did not write are not evicted.
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("not a cached response"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFileCache(dir, 30)
+	if err != nil {
+		t.Fatalf("new file cache error: %v", err)
+	}
+	_ = f.Set(ctx, "a", []byte("hello"), 0)
+	_ = f.Set(ctx, "b", []byte("expired"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := f.Get(ctx, "b"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	// Reopening keeps the entries, and the size limit evicts the oldest.
+	if f, err = NewFileCache(dir, 30); err != nil {
+		t.Fatalf("reopen file cache error: %v", err)
+	}
+	if v, ok, err := f.Get(ctx, "a"); !ok || err != nil || string(v) != "hello" {
+		t.Errorf("Get(a) = %q, %v, %v", v, ok, err)
+	}
+	_ = f.Set(ctx, "c", []byte("a larger one"), 0)
+	if _, ok, _ := f.Get(ctx, "a"); ok {
+		t.Error("entry beyond the size limit was not evicted")
+	}
+	if v, ok, _ := f.Get(ctx, "c"); !ok || string(v) != "a larger one" {
+		t.Errorf("Get(c) = %q, %v", v, ok)
+	}
+
+	// Keys are not paths.
+	if err = f.Set(ctx, "../escaped", []byte("x"), 0); err != nil {
+		t.Fatalf("Set(../escaped) error: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "..", "escaped")); err == nil {
+		t.Error("key escaped the cache directory")
+	}
+	if v, ok, _ := f.Get(ctx, "../escaped"); !ok || string(v) != "x" {
+		t.Errorf("Get(../escaped) = %q, %v", v, ok)
+	}
+	if _, err = NewFileCache(dir, 1); err != nil {
+		t.Fatalf("reopen file cache error: %v", err)
+	}
+	if _, err = os.Stat(other); err != nil {
+		t.Errorf("file of the directory evicted: %v", err)
+	}
+}
@@ -0,0 +1,453 @@
+// Package cassette records HTTP interactions with the API into cassette files
+// and replays them offline, so tests of code using openai.Client are
+// deterministic and need no API token.
+//
+// A Recorder is an http.RoundTripper:
+//
+//	rec, err := cassette.New("testdata/chat.json", cassette.ModeReplay)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//	c := openai.NewClient(os.Getenv("OPENAI_API_KEY"))
+//	c.HTTPClient = &http.Client{Transport: rec}
+//
+// Streamed responses are recorded chunk by chunk together with the delay
+// before each chunk, and replayed with the same chunking. The Authorization,
+// OpenAI-Organization and cookie headers are never written to a cassette.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Mode selects whether a Recorder talks to the real API.
+type Mode int
+
+const (
+	// ModeReplay serves every request from the cassette and fails requests
+	// that have no recorded interaction.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request to the API and records it, replacing
+	// the cassette when the Recorder is stopped.
+	ModeRecord
+	// ModeReplayOrRecord replays an existing cassette, and records a new one
+	// when the file does not exist yet.
+	ModeReplayOrRecord
+)
+
+// ErrNoInteraction is returned in replay mode for requests that do not match
+// any unused interaction of the cassette.
+var ErrNoInteraction = errors.New("cassette: no matching interaction")
+
+// scrubbedHeaders are replaced in requests and responses before an
+// interaction is stored.
+var scrubbedHeaders = []string{"Authorization", "Openai-Organization", "Cookie", "Set-Cookie"}
+
+const scrubbed = "[SCRUBBED]"
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	// Note describes a cassette that was not recorded, such as a fixture
+	// written by hand. Recording the cassette again drops it.
+	Note         string         `json:"note,omitempty"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+
+	used bool
+}
+
+// EncodingBase64 is the encoding of bodies and chunks that are not valid
+// UTF-8, such as uploaded images and audio, stored base64-encoded.
+const EncodingBase64 = "base64"
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// BodyEncoding is EncodingBase64 for a body that is not valid UTF-8,
+	// empty for a body stored as is.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// BodyBytes returns the decoded body of the request.
+func (r *Request) BodyBytes() []byte {
+	data, _ := decode(r.Body, r.BodyEncoding)
+	return data
+}
+
+// Response is a recorded HTTP response. Its body is stored as the chunks it
+// was read in.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Chunks     []Chunk     `json:"chunks,omitempty"`
+}
+
+// Chunk is a part of a response body and the delay after which it was
+// received, counted from the previous chunk or from the response headers.
+type Chunk struct {
+	Delay time.Duration `json:"delay,omitempty"`
+	Data  string        `json:"data"`
+	// Encoding is EncodingBase64 for data that is not valid UTF-8, empty for
+	// data stored as is.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Bytes returns the decoded data of the chunk.
+func (c *Chunk) Bytes() []byte {
+	data, _ := decode(c.Data, c.Encoding)
+	return data
+}
+
+// encode stores data as is when it is valid UTF-8, which JSON strings
+// preserve, or else base64-encoded.
+func encode(data []byte) (string, string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return base64.StdEncoding.EncodeToString(data), EncodingBase64
+}
+
+func decode(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(s), nil
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return nil, fmt.Errorf("cassette: unknown encoding %q", encoding)
+}
+
+// check reports data that cannot be decoded.
+func (c *Cassette) check() error {
+	for n, i := range c.Interactions {
+		if _, err := decode(i.Request.Body, i.Request.BodyEncoding); err != nil {
+			return fmt.Errorf("interaction %d: request body: %w", n, err)
+		}
+		for _, chunk := range i.Response.Chunks {
+			if _, err := decode(chunk.Data, chunk.Encoding); err != nil {
+				return fmt.Errorf("interaction %d: response chunk: %w", n, err)
+			}
+		}
+	}
+	return nil
+}
+
+type config struct {
+	transport http.RoundTripper
+	timing    bool
+	match     func(r *http.Request, body []byte, i *Interaction) bool
+}
+
+// Option configures a Recorder.
+type Option func(*config)
+
+// WithTransport sets the transport used to reach the API while recording,
+// http.DefaultTransport by default.
+func WithTransport(t http.RoundTripper) Option {
+	return func(c *config) {
+		c.transport = t
+	}
+}
+
+// WithTiming makes replayed responses wait the recorded delay before each
+// chunk. By default chunks are served immediately.
+func WithTiming(timing bool) Option {
+	return func(c *config) {
+		c.timing = timing
+	}
+}
+
+// WithMatcher replaces the function deciding whether a request matches a
+// recorded interaction. The default matches the method, the path and the
+// request body, JSON bodies being compared after normalization.
+func WithMatcher(match func(r *http.Request, body []byte, i *Interaction) bool) Option {
+	return func(c *config) {
+		c.match = match
+	}
+}
+
+// Recorder records or replays the interactions of a cassette file.
+type Recorder struct {
+	path string
+	mode Mode
+	cfg  config
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// New returns a Recorder for the cassette at path. In replay mode the file
+// must exist.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path: path,
+		mode: mode,
+		cfg: config{
+			transport: http.DefaultTransport,
+			match:     DefaultMatcher,
+		},
+	}
+	for _, opt := range opts {
+		opt(&r.cfg)
+	}
+
+	if mode == ModeReplayOrRecord {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.mode = ModeRecord
+		} else {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &r.cassette); err == nil {
+			err = r.cassette.check()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Mode returns the mode the Recorder operates in.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Stop writes the cassette file when recording. Responses that are still
+// being read are stored as far as they have been received.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	var found *Interaction
+	for _, i := range r.cassette.Interactions {
+		if !i.used && r.cfg.match(req, body, i) {
+			i.used = true
+			found = i
+			break
+		}
+	}
+	r.mu.Unlock()
+	if found == nil {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, req.URL)
+	}
+
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", found.Response.StatusCode, http.StatusText(found.Response.StatusCode)),
+		StatusCode: found.Response.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     found.Response.Header.Clone(),
+		Body: &replayBody{
+			chunks: found.Response.Chunks,
+			timing: r.cfg.timing,
+			done:   req.Context().Done(),
+		},
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	res, err := r.cfg.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: scrub(req.Header),
+		},
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     scrub(res.Header),
+		},
+	}
+	i.Request.Body, i.Request.BodyEncoding = encode(body)
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+
+	res.Body = &recordBody{r: r, i: i, body: res.Body, last: time.Now()}
+	return res, nil
+}
+
+// scrub returns a copy of header whose scrubbedHeaders are replaced.
+func scrub(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range scrubbedHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, scrubbed)
+		}
+	}
+	return header
+}
+
+// recordBody stores every read of a response body as a chunk.
+type recordBody struct {
+	r    *Recorder
+	i    *Interaction
+	body io.ReadCloser
+	last time.Time
+}
+
+func (b *recordBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		now := time.Now()
+		chunk := Chunk{Delay: now.Sub(b.last)}
+		chunk.Data, chunk.Encoding = encode(p[:n])
+		b.r.mu.Lock()
+		b.i.Response.Chunks = append(b.i.Response.Chunks, chunk)
+		b.r.mu.Unlock()
+		b.last = now
+	}
+	return n, err
+}
+
+func (b *recordBody) Close() error {
+	return b.body.Close()
+}
+
+// replayBody serves recorded chunks.
+type replayBody struct {
+	chunks []Chunk
+	timing bool
+	done   <-chan struct{}
+	// pending is the part of the current chunk that has not been read yet.
+	pending []byte
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	if len(b.pending) == 0 {
+		if len(b.chunks) == 0 {
+			return 0, io.EOF
+		}
+		chunk := b.chunks[0]
+		b.chunks = b.chunks[1:]
+		if b.timing && chunk.Delay > 0 {
+			t := time.NewTimer(chunk.Delay)
+			select {
+			case <-t.C:
+			case <-b.done:
+				t.Stop()
+				return 0, errors.New("cassette: request canceled")
+			}
+		}
+		b.pending = chunk.Bytes()
+	}
+	n := copy(p, b.pending)
+	b.pending = b.pending[n:]
+	return n, nil
+}
+
+func (b *replayBody) Close() error {
+	return nil
+}
+
+// DefaultMatcher matches requests on method, path and body. JSON bodies are
+// compared after normalization, so key order and whitespace do not matter.
+// Other bodies, such as multipart forms with random boundaries, are ignored.
+func DefaultMatcher(r *http.Request, body []byte, i *Interaction) bool {
+	if r.Method != i.Request.Method {
+		return false
+	}
+	if r.URL.Path != requestPath(i.Request.URL) {
+		return false
+	}
+	got, gotOK := normalizeJSON(body)
+	want, wantOK := normalizeJSON(i.Request.BodyBytes())
+	if gotOK != wantOK {
+		return false
+	}
+	return !gotOK || got == want
+}
+
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Path
+}
+
+// normalizeJSON re-encodes a JSON document with sorted keys and no
+// insignificant whitespace. It reports false when data is not JSON.
+func normalizeJSON(data []byte) (string, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return "", false
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", false
+	}
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(normalized), true
+}
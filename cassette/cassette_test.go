@@ -0,0 +1,127 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Openai-Organization", "org-secret")
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+		for _, data := range []string{`{"n":1}`, `{"n":2}`, `[DONE]`} {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "stream.json")
+
+	rec, err := New(path, ModeReplayOrRecord)
+	if err != nil {
+		t.Fatalf("new recorder error: %v", err)
+	}
+	if rec.Mode() != ModeRecord {
+		t.Fatalf("mode = %v, want record for a missing cassette", rec.Mode())
+	}
+	recorded := get(t, rec, srv.URL+"/v1/chat/completions", `{"model":"gpt-4", "stream":true}`)
+	if err = rec.Stop(); err != nil {
+		t.Fatalf("stop error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette error: %v", err)
+	}
+	for _, secret := range []string{"secret-token", "org-secret", "cookie-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	rec, err = New(path, ModeReplay, WithTiming(true))
+	if err != nil {
+		t.Fatalf("new recorder error: %v", err)
+	}
+	start := time.Now()
+	replayed := get(t, rec, "https://api.openai.com/v1/chat/completions", `{"stream": true,"model":"gpt-4"}`)
+	if replayed != recorded {
+		t.Errorf("replayed %q, want %q", replayed, recorded)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("replay did not honor the recorded chunk timing")
+	}
+
+	_, err = rec.RoundTrip(newRequest(t, "https://api.openai.com/v1/chat/completions", `{"model":"gpt-4"}`))
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("error = %v, want %v", err, ErrNoInteraction)
+	}
+}
+
+func TestRecorder_binary(t *testing.T) {
+	image := "\x89PNG\r\n\x1a\n\xff\xfe"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "binary.json")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("new recorder error: %v", err)
+	}
+	recorded := get(t, rec, srv.URL+"/v1/images/edits", image)
+	if err = rec.Stop(); err != nil {
+		t.Fatalf("stop error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette error: %v", err)
+	}
+	if !strings.Contains(string(data), `"body_encoding": "base64"`) || !strings.Contains(string(data), `"encoding": "base64"`) {
+		t.Errorf("cassette does not record base64 bodies:\n%s", data)
+	}
+
+	if rec, err = New(path, ModeReplay); err != nil {
+		t.Fatalf("new recorder error: %v", err)
+	}
+	if replayed := get(t, rec, "https://api.openai.com/v1/images/edits", image); replayed != recorded || replayed != image {
+		t.Errorf("replayed %q, recorded %q, want %q", replayed, recorded, image)
+	}
+}
+
+func newRequest(t *testing.T, url, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request error: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-token")
+	return req
+}
+
+func get(t *testing.T, rec *Recorder, url, body string) string {
+	t.Helper()
+	res, err := (&http.Client{Transport: rec}).Do(newRequest(t, url, body))
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read body error: %v", err)
+	}
+	return string(data)
+}
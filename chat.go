@@ -2,6 +2,8 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
@@ -9,11 +11,111 @@ const (
 	RoleUser      = "user"
 	RoleSystem    = "system"
 	RoleAssistant = "assistant"
+	RoleTool      = "tool"
 )
 
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content,omitempty"`
+	// MultiContent is sent instead of Content when set, to mix text and
+	// images in a message.
+	MultiContent []ChatMessagePart `json:"-"`
+	// Name tells apart participants of the same role.
+	Name string `json:"name,omitempty"`
+	// ToolCalls are the tools an assistant message calls.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the call a tool message answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	type plain ChatMessage
+	if len(m.MultiContent) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []ChatMessagePart `json:"content"`
+	}{plain(m), m.MultiContent})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Content given as parts is
+// stored in MultiContent.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type plain ChatMessage
+	var msg struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*m = ChatMessage(msg.plain)
+	switch {
+	case len(msg.Content) == 0 || string(msg.Content) == "null":
+		return nil
+	case msg.Content[0] == '[':
+		return json.Unmarshal(msg.Content, &m.MultiContent)
+	default:
+		return json.Unmarshal(msg.Content, &m.Content)
+	}
+}
+
+const (
+	ChatMessagePartTypeText     = "text"
+	ChatMessagePartTypeImageURL = "image_url"
+)
+
+const (
+	ImageDetailAuto = "auto"
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+)
+
+// ChatMessagePart is a part of the content of a message, text or an image.
+type ChatMessagePart struct {
+	Type     string               `json:"type"`
+	Text     string               `json:"text,omitempty"`
+	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
+}
+
+type ChatMessageImageURL struct {
+	// URL is the URL of the image or its data URL.
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+const ToolTypeFunction = "function"
+
+// Tool is a tool the model may call.
+type Tool struct {
+	Type     string              `json:"type"`
+	Function *FunctionDefinition `json:"function,omitempty"`
+}
+
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON Schema of the arguments, e.g. a
+	// json.RawMessage or a map.
+	Parameters any  `json:"parameters,omitempty"`
+	Strict     bool `json:"strict,omitempty"`
+}
+
+// ToolCall is a call of a tool by the model.
+type ToolCall struct {
+	// Index identifies the call in the fragments of a stream.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name string `json:"name,omitempty"`
+	// Arguments are JSON encoded.
+	Arguments string `json:"arguments,omitempty"`
 }
 
 type ChatRequestBody struct {
@@ -23,12 +125,82 @@ type ChatRequestBody struct {
 	TopP             float32        `json:"top_p,omitempty"`
 	N                int            `json:"n,omitempty"`
 	Stream           bool           `json:"stream,omitempty"`
+	StreamOptions    *StreamOptions `json:"stream_options,omitempty"`
 	Stop             string         `json:"stop,omitempty"`
 	MaxTokens        int            `json:"max_tokens,omitempty"`
 	PresencePenalty  float32        `json:"presence_penalty,omitempty"`
 	FrequencyPenalty float32        `json:"frequency_penalty,omitempty"`
 	LogitBias        map[string]int `json:"logit_bias,omitempty"`
-	User             string         `json:"user,omitempty"`
+	// Logprobs returns the log probabilities of the tokens of the reply.
+	Logprobs bool `json:"logprobs,omitempty"`
+	// TopLogprobs also returns the most likely alternatives of every token.
+	TopLogprobs int    `json:"top_logprobs,omitempty"`
+	User        string `json:"user,omitempty"`
+	Tools       []Tool `json:"tools,omitempty"`
+	// ToolChoice is "none", "auto", "required" or a tool to call.
+	ToolChoice any `json:"tool_choice,omitempty"`
+
+	// ExtraFields are merged into the body, replacing the fields of the same
+	// name, to send parameters this type does not model yet.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b ChatRequestBody) MarshalJSON() ([]byte, error) {
+	type plain ChatRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b ChatRequestBody) Validate() error {
+	var v validation
+	v.required("model", b.Model)
+	if len(b.Messages) == 0 {
+		v.add("messages", "not provided")
+	}
+	for i, m := range b.Messages {
+		field := fmt.Sprintf("messages[%d]", i)
+		if m == nil {
+			v.add(field, "not provided")
+			continue
+		}
+		v.required(field+".role", m.Role)
+		v.oneOf(field+".role", m.Role, RoleSystem, RoleUser, RoleAssistant, RoleTool)
+		if m.Role == RoleTool {
+			v.required(field+".tool_call_id", m.ToolCallID)
+		}
+		for j, p := range m.MultiContent {
+			part := fmt.Sprintf("%s.content[%d]", field, j)
+			v.oneOf(part+".type", p.Type, ChatMessagePartTypeText, ChatMessagePartTypeImageURL)
+			if p.Type == ChatMessagePartTypeImageURL && (p.ImageURL == nil || p.ImageURL.URL == "") {
+				v.add(part+".image_url", "not provided")
+			}
+		}
+	}
+	v.floatRange("temperature", b.Temperature, 0, 2)
+	v.floatRange("top_p", b.TopP, 0, 1)
+	v.intRange("n", b.N, 1, 128)
+	if b.MaxTokens < 0 {
+		v.add("max_tokens", "must not be negative")
+	}
+	v.floatRange("presence_penalty", b.PresencePenalty, -2, 2)
+	v.floatRange("frequency_penalty", b.FrequencyPenalty, -2, 2)
+	v.logitBias("logit_bias", b.LogitBias)
+	v.intRange("top_logprobs", b.TopLogprobs, 0, 20)
+	if b.TopLogprobs > 0 && !b.Logprobs {
+		v.add("top_logprobs", "requires logprobs")
+	}
+	for i, t := range b.Tools {
+		if t.Function == nil || t.Function.Name == "" {
+			v.add(fmt.Sprintf("tools[%d].function.name", i), "not provided")
+		}
+	}
+	return v.err()
+}
+
+type StreamOptions struct {
+	// IncludeUsage makes the last chunk of a stream report the usage.
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 type ChatChoice struct {
@@ -36,45 +208,67 @@ type ChatChoice struct {
 	Message      *ChatMessage `json:"message"`
 	Delta        *ChatMessage `json:"delta"`
 	FinishReason *string      `json:"finish_reason"`
+	// Logprobs are set when ChatRequestBody.Logprobs is.
+	Logprobs *ChatLogprobs `json:"logprobs,omitempty"`
+}
+
+type ChatLogprobs struct {
+	Content []ChatTokenLogprob `json:"content"`
+}
+
+type ChatTokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	// Bytes are the UTF-8 bytes of the token, which may be part of a
+	// character.
+	Bytes       []int            `json:"bytes"`
+	TopLogprobs []ChatTopLogprob `json:"top_logprobs"`
+}
+
+type ChatTopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes"`
 }
 
 type ChatStreamChunk struct {
-	ID      string        `json:"id"`
-	Object  string        `json:"object"`
-	Created int           `json:"created"`
-	Choices []*ChatChoice `json:"choices"`
+	ID                string        `json:"id"`
+	Object            string        `json:"object"`
+	Created           int           `json:"created"`
+	Model             string        `json:"model"`
+	SystemFingerprint string        `json:"system_fingerprint,omitempty"`
+	Choices           []*ChatChoice `json:"choices"`
+	// Usage is set on the last chunk when StreamOptions.IncludeUsage is.
+	Usage *TokensUsage `json:"usage,omitempty"`
+
+	ResponseMeta
 }
 
 type ChatResponseBody struct {
-	Usage      TokensUsage   `json:"usage"`
-	ID         string        `json:"id"`
-	Object     string        `json:"object"`
-	Created    int           `json:"created"`
-	Choices    []*ChatChoice `json:"choices"`
-	StreamChan chan *ChatStreamChunk
+	Usage             TokensUsage           `json:"usage"`
+	ID                string                `json:"id"`
+	Object            string                `json:"object"`
+	Created           int                   `json:"created"`
+	Model             string                `json:"model"`
+	SystemFingerprint string                `json:"system_fingerprint,omitempty"`
+	Choices           []*ChatChoice         `json:"choices"`
+	StreamChan        chan *ChatStreamChunk `json:"-"`
+
+	ResponseMeta
 }
 
 // CreateChatCompletion Create a completion for the chat message
 // POST https://api.openai.com/v1/chat/completions
 func (c *Client) CreateChatCompletion(
 	ctx context.Context,
-	body ChatRequestBody) (*ChatResponseBody, error) {
-	switch body.Model {
-	case GPT4, GPT40314, GPT432k, GPT432k0314, GPT35Turbo, GPT35Turbo0310:
-	default:
-		return nil, ErrInvalidModel
-	}
-
+	body ChatRequestBody,
+	opts ...RequestOption) (*ChatResponseBody, error) {
 	const apiURL = apiURLPrefix + "/v1/chat/completions"
-	req, err := c.newRequest(ctx, http.MethodPost, apiURL, body)
-	if err != nil {
-		return nil, err
-	}
 	responseBody := &ChatResponseBody{}
 	if body.Stream {
 		responseBody.StreamChan = make(chan *ChatStreamChunk, 128)
 	}
-	if err = c.getRequest(req, responseBody); err != nil {
+	if err := c.do(ctx, "CreateChatCompletion", http.MethodPost, apiURL, body, responseBody, opts...); err != nil {
 		return nil, err
 	}
 	return responseBody, nil
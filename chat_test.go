@@ -2,14 +2,14 @@ package openai
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"os/signal"
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestClient_CreateChatCompletion(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "chat_stream")
 	body, err := c.CreateChatCompletion(context.Background(), ChatRequestBody{
 		Model:  GPT35Turbo,
 		Stream: true,
@@ -20,43 +20,57 @@ func TestClient_CreateChatCompletion(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Create chat completion error: %v", err)
 	}
-	t.Logf("%v", body)
-
-	go func() {
-		for {
-			select {
-			case chunk, ok := <-body.StreamChan:
-				if !ok {
-					return
-				}
-				//chunk.ID
-				//chunk.Created
-				//chunk.Object
-				//chunk.Choices
-				if len(chunk.Choices) > 0 {
-					choice := chunk.Choices[0]
-					if choice.FinishReason != nil {
-						fmt.Print("\n\n")
-						break
-					}
-					if choice.Message != nil {
-						fmt.Printf("chunk: %v", choice.Message)
-					} else if choice.Delta != nil {
-						if choice.Delta.Role != "" {
-							fmt.Printf("%s\n", choice.Delta.Role)
-						} else if choice.Delta.Content != "" {
-							fmt.Print(choice.Delta.Content)
-						}
-					}
-				}
-
-				//default:
 
+	var (
+		role         string
+		content      strings.Builder
+		finishReason string
+	)
+	for chunk := range body.StreamChan {
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		choice := chunk.Choices[0]
+		if choice.FinishReason != nil {
+			finishReason = *choice.FinishReason
+		}
+		if choice.Delta != nil {
+			if choice.Delta.Role != "" {
+				role = choice.Delta.Role
 			}
+			content.WriteString(choice.Delta.Content)
 		}
-	}()
+	}
+	t.Logf("%s: %s", role, content.String())
+	if role != RoleAssistant || content.Len() == 0 || finishReason != "stop" {
+		t.Errorf("got role %q, content %q, finish reason %q", role, content.String(), finishReason)
+	}
+}
 
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt)
-	t.Logf("quit: %v", <-quit)
+func TestChatMessage_MultiContent(t *testing.T) {
+	m := ChatMessage{Role: RoleUser, MultiContent: []ChatMessagePart{
+		{Type: ChatMessagePartTypeText, Text: "what?"},
+		{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: "https://example.com/a.png"}},
+	}}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"what?"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s", data)
+	}
+	var got ChatMessage
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Unmarshal() = %+v", got)
+	}
+	if err = json.Unmarshal([]byte(`{"role":"assistant","content":null,"tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "" || got.MultiContent != nil || len(got.ToolCalls) != 1 || got.ToolCalls[0].Function.Name != "f" {
+		t.Errorf("Unmarshal() = %+v", got)
+	}
 }
@@ -0,0 +1,145 @@
+package openai
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// StreamAccumulator rebuilds the response of a streamed chat completion from
+// its chunks: the content, tool calls, finish reason and log probabilities of
+// every choice, and the usage when the last chunk reports it. It is not safe
+// for concurrent use.
+type StreamAccumulator struct {
+	res     ChatResponseBody
+	choices map[int]*accumulatedChoice
+}
+
+type accumulatedChoice struct {
+	role         string
+	content      strings.Builder
+	toolCalls    []*ToolCall
+	finishReason *string
+	logprobs     *ChatLogprobs
+}
+
+// Add merges a chunk into the response.
+func (a *StreamAccumulator) Add(chunk *ChatStreamChunk) {
+	if a.choices == nil {
+		a.choices = make(map[int]*accumulatedChoice)
+	}
+	if a.res.ID == "" {
+		a.res.ID = chunk.ID
+		a.res.Created = chunk.Created
+	}
+	if chunk.Model != "" {
+		a.res.Model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		a.res.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		a.res.Usage = *chunk.Usage
+	}
+	for _, c := range chunk.Choices {
+		choice, ok := a.choices[c.Index]
+		if !ok {
+			choice = &accumulatedChoice{role: RoleAssistant}
+			a.choices[c.Index] = choice
+		}
+		if c.FinishReason != nil {
+			reason := *c.FinishReason
+			choice.finishReason = &reason
+		}
+		if c.Logprobs != nil {
+			if choice.logprobs == nil {
+				choice.logprobs = &ChatLogprobs{}
+			}
+			choice.logprobs.Content = append(choice.logprobs.Content, c.Logprobs.Content...)
+		}
+		if c.Delta == nil {
+			continue
+		}
+		if c.Delta.Role != "" {
+			choice.role = c.Delta.Role
+		}
+		choice.content.WriteString(c.Delta.Content)
+		for _, fragment := range c.Delta.ToolCalls {
+			choice.addToolCall(fragment)
+		}
+	}
+}
+
+// addToolCall merges the fragment of a tool call: the first one of a call
+// carries its ID, type and name, the next ones pieces of its arguments.
+func (c *accumulatedChoice) addToolCall(fragment ToolCall) {
+	var call *ToolCall
+	switch {
+	case fragment.Index != nil:
+		for _, tc := range c.toolCalls {
+			if *tc.Index == *fragment.Index {
+				call = tc
+			}
+		}
+	case fragment.ID == "" && len(c.toolCalls) > 0:
+		call = c.toolCalls[len(c.toolCalls)-1]
+	}
+	if call == nil {
+		index := len(c.toolCalls)
+		if fragment.Index != nil {
+			index = *fragment.Index
+		}
+		call = &ToolCall{Index: &index}
+		c.toolCalls = append(c.toolCalls, call)
+	}
+	if fragment.ID != "" {
+		call.ID = fragment.ID
+	}
+	if fragment.Type != "" {
+		call.Type = fragment.Type
+	}
+	call.Function.Name += fragment.Function.Name
+	call.Function.Arguments += fragment.Function.Arguments
+}
+
+// Response returns the response accumulated so far, as a call that was not
+// streamed would have returned it.
+func (a *StreamAccumulator) Response() *ChatResponseBody {
+	res := a.res
+	res.Object = "chat.completion"
+	res.Choices = make([]*ChatChoice, 0, len(a.choices))
+	for index, c := range a.choices {
+		message := &ChatMessage{Role: c.role, Content: c.content.String()}
+		if len(c.toolCalls) > 0 {
+			calls := make([]*ToolCall, len(c.toolCalls))
+			copy(calls, c.toolCalls)
+			sort.Slice(calls, func(i, j int) bool { return *calls[i].Index < *calls[j].Index })
+			message.ToolCalls = make([]ToolCall, len(calls))
+			for i, call := range calls {
+				message.ToolCalls[i] = *call
+				message.ToolCalls[i].Index = nil
+			}
+		}
+		choice := &ChatChoice{Index: index, Message: message, FinishReason: c.finishReason}
+		if c.logprobs != nil {
+			choice.Logprobs = &ChatLogprobs{Content: append([]ChatTokenLogprob(nil), c.logprobs.Content...)}
+		}
+		res.Choices = append(res.Choices, choice)
+	}
+	sort.Slice(res.Choices, func(i, j int) bool { return res.Choices[i].Index < res.Choices[j].Index })
+	return &res
+}
+
+// AccumulateStream reads a stream until it is closed and returns the
+// response it accumulated. It returns the error of ctx when the stream was
+// closed because ctx was done.
+func AccumulateStream(ctx context.Context, stream <-chan *ChatStreamChunk) (*ChatResponseBody, error) {
+	var a StreamAccumulator
+	for chunk := range stream {
+		a.Add(chunk)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.Response(), nil
+}
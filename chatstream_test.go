@@ -0,0 +1,67 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStreamAccumulator(t *testing.T) {
+	// Two choices, the second one calling two tools in fragments.
+	chunks := []string{
+		`{"id":"c1","object":"chat.completion.chunk","created":7,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":""}},{"index":1,"delta":{"role":"assistant"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"Hel"},"logprobs":{"content":[{"token":"Hel","logprob":-0.1,"bytes":[72,101,108],"top_logprobs":[]}]}}]}`,
+		`{"id":"c1","choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get","arguments":""}}]}}]}`,
+		`{"id":"c1","choices":[{"index":1,"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"put","arguments":"{}"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"k\":"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"lo"},"logprobs":{"content":[{"token":"lo","logprob":-0.2,"bytes":[108,111],"top_logprobs":[]}]}}]}`,
+		`{"id":"c1","choices":[{"index":1,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"stop"},{"index":1,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"c1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":9,"total_tokens":14}}`,
+	}
+	stream := make(chan *ChatStreamChunk, len(chunks))
+	for _, data := range chunks {
+		var chunk ChatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatal(err)
+		}
+		stream <- &chunk
+	}
+	close(stream)
+	res, err := AccumulateStream(context.Background(), stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The response of the same completion, not streamed.
+	var want ChatResponseBody
+	if err = json.Unmarshal([]byte(`{
+		"id": "c1", "object": "chat.completion", "created": 7, "model": "gpt-4o",
+		"choices": [
+			{"index": 0, "message": {"role": "assistant", "content": "Hello"}, "finish_reason": "stop",
+			 "logprobs": {"content": [
+				{"token": "Hel", "logprob": -0.1, "bytes": [72, 101, 108], "top_logprobs": []},
+				{"token": "lo", "logprob": -0.2, "bytes": [108, 111], "top_logprobs": []}]}},
+			{"index": 1, "message": {"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_a", "type": "function", "function": {"name": "get", "arguments": "{\"k\":1}"}},
+				{"id": "call_b", "type": "function", "function": {"name": "put", "arguments": "{}"}}]},
+			 "finish_reason": "tool_calls"}
+		],
+		"usage": {"prompt_tokens": 5, "completion_tokens": 9, "total_tokens": 14}
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, &want) {
+		got, _ := json.Marshal(res)
+		t.Errorf("AccumulateStream() = %s", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	closed := make(chan *ChatStreamChunk)
+	close(closed)
+	if _, err = AccumulateStream(ctx, closed); err != context.Canceled {
+		t.Errorf("AccumulateStream() canceled = %v", err)
+	}
+}
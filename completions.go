@@ -21,6 +21,41 @@ type CompletionRequestBody struct {
 	BestOf          int            `json:"best_of,omitempty"`
 	LogitBias       map[string]int `json:"logit_bias,omitempty"`
 	User            string         `json:"user,omitempty"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b CompletionRequestBody) MarshalJSON() ([]byte, error) {
+	type plain CompletionRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b CompletionRequestBody) Validate() error {
+	var v validation
+	v.required("model", b.Model)
+	if b.MaxTokens < 0 {
+		v.add("max_tokens", "must not be negative")
+	}
+	v.floatRange("temperature", b.Temperature, 0, 2)
+	v.floatRange("top_p", b.TopP, 0, 1)
+	v.intRange("n", b.N, 1, 128)
+	v.intRange("logprobs", b.Logprobs, 0, 5)
+	v.floatRange("presence_penalty", b.PresencePenalty, -2, 2)
+	v.intRange("best_of", b.BestOf, 1, 20)
+	if b.BestOf > 0 && b.BestOf < b.N {
+		v.add("best_of", "must be greater than or equal to `n`")
+	}
+	if b.BestOf > 1 && b.Stream {
+		v.add("best_of", "cannot be used with `stream`")
+	}
+	if b.Echo && b.Suffix != "" {
+		v.add("echo", "cannot be used with `suffix`")
+	}
+	v.logitBias("logit_bias", b.LogitBias)
+	return v.err()
 }
 
 type CompletionChoice struct {
@@ -37,21 +72,19 @@ type CompletionResponseBody struct {
 	Model   string             `json:"model"`
 	Choices []CompletionChoice `json:"choices"`
 	Usage   TokensUsage        `json:"usage"`
+
+	ResponseMeta
 }
 
 // CreateCompletions
 // POST https://api.openai.com/v1/completions
 func (c *Client) CreateCompletions(
 	ctx context.Context,
-	reqBody CompletionRequestBody) (resBody CompletionResponseBody, err error) {
+	reqBody CompletionRequestBody,
+	opts ...RequestOption) (resBody CompletionResponseBody, err error) {
 	const apiURL = apiURLPrefix + "/v1/completions"
 
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, resBody)
+	err = c.do(ctx, "CreateCompletions", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 
 	return
 }
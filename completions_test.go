@@ -1 +1,21 @@
 package openai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClient_CreateCompletions(t *testing.T) {
+	c := newCassetteClient(t, "create_completions")
+	body, err := c.CreateCompletions(context.Background(), CompletionRequestBody{
+		Model:     "gpt-3.5-turbo-instruct",
+		Prompt:    "Say this is a test",
+		MaxTokens: 7,
+	})
+	if err != nil {
+		t.Fatalf("Create completions error: %v", err)
+	}
+	if len(body.Choices) != 1 || body.Usage.PromptTokens == 0 {
+		t.Errorf("Create completions = %+v", body)
+	}
+}
@@ -0,0 +1,375 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/im15/openai-api-go/tokenizer"
+)
+
+// ContextStrategy shortens the messages of a chat request before it is sent,
+// so that a long conversation keeps fitting in the context window of the
+// model.
+//
+// The strategies of this package keep the system messages and the last user
+// message, and never separate the tool calls of an assistant message from
+// their results.
+type ContextStrategy interface {
+	Fit(ctx context.Context, body *ChatRequestBody) error
+}
+
+// DropOldest drops the oldest turns until at most MaxMessages messages other
+// than system ones are left. A turn starts with a user message; once a
+// single turn is left, its oldest replies are dropped.
+type DropOldest struct {
+	MaxMessages int
+}
+
+// Fit implements ContextStrategy.
+func (s DropOldest) Fit(_ context.Context, body *ChatRequestBody) error {
+	body.Messages = dropOldest(body.Messages, func(messages []*ChatMessage) bool {
+		n := 0
+		for _, m := range messages {
+			if m.Role != RoleSystem {
+				n++
+			}
+		}
+		return n <= s.MaxMessages
+	})
+	return nil
+}
+
+// KeepLastTurns keeps the last N turns, each starting with a user message,
+// and the system messages.
+type KeepLastTurns struct {
+	N int
+}
+
+// Fit implements ContextStrategy.
+func (s KeepLastTurns) Fit(_ context.Context, body *ChatRequestBody) error {
+	turns := turnStarts(body.Messages)
+	if s.N <= 0 || len(turns) <= s.N {
+		return nil
+	}
+	body.Messages = keepFrom(body.Messages, turns[len(turns)-s.N])
+	return nil
+}
+
+// TokenBudget drops the oldest turns until the prompt fits in MaxTokens
+// tokens, or else in the context window of the model less the completion's:
+// the MaxTokens of the request, or else the MaxOutputTokens of the model or
+// a minimum of 1024 tokens. It returns ErrContextWindowExceeded when the messages it
+// keeps do not fit.
+type TokenBudget struct {
+	MaxTokens int
+	// Encoding counts the tokens, the encoding of the model when nil, or
+	// a token per byte for models without a known encoding or rank file.
+	Encoding *tokenizer.Encoding
+	// Models provides the context windows, DefaultModels when nil.
+	Models *ModelRegistry
+}
+
+// Fit implements ContextStrategy.
+func (s TokenBudget) Fit(_ context.Context, body *ChatRequestBody) error {
+	budget, err := promptBudget(*body, s.MaxTokens, s.Models)
+	if err != nil {
+		return err
+	}
+	enc := encodingFor(s.Encoding, body.Model)
+	var countErr error
+	fits := func(messages []*ChatMessage) bool {
+		b := *body
+		b.Messages = messages
+		n, err := countPromptTokens(enc, b)
+		if err != nil {
+			countErr = err
+			return true
+		}
+		return n <= budget
+	}
+	messages := dropOldest(body.Messages, fits)
+	if countErr != nil {
+		return countErr
+	}
+	if !fits(messages) {
+		return fmt.Errorf("%w: the last turn exceeds %d prompt tokens", ErrContextWindowExceeded, budget)
+	}
+	body.Messages = messages
+	return nil
+}
+
+// DefaultSummaryPrompt instructs the model summarizing a conversation.
+const DefaultSummaryPrompt = "Summarize the conversation below for an assistant that will continue it. " +
+	"Keep the facts, decisions, names and open questions; leave out pleasantries. " +
+	"When it starts with a previous summary, merge it into the new one."
+
+// Summarizer replaces the oldest turns with a summary written by the model
+// once the prompt exceeds MaxTokens tokens, or else the context window of the
+// model less the completion's, as for TokenBudget. The summary is a system message
+// taking the place of the turns.
+//
+// Summaries are rolling: the summary of a conversation is kept and, when the
+// conversation grows again, the model merges it with the turns added since
+// rather than reading them all again. The summary may still leave the prompt
+// over the limit; a TokenBudget following the Summarizer trims what remains.
+// A Summarizer is safe for concurrent use, and may serve many conversations.
+type Summarizer struct {
+	// API writes the summaries.
+	API ChatAPI
+	// Model writes the summaries, the model of the request when "".
+	Model string
+	// Prompt instructs the model, DefaultSummaryPrompt when "".
+	Prompt    string
+	MaxTokens int
+	// KeepTurns is the number of recent turns kept verbatim, 1 when zero.
+	KeepTurns int
+	// Encoding counts the tokens, the encoding of the model when nil, or
+	// a token per byte for models without a known encoding or rank file.
+	Encoding *tokenizer.Encoding
+	// Models provides the context windows, DefaultModels when nil.
+	Models *ModelRegistry
+
+	mu sync.Mutex
+	// summaries maps the hash of the summarized messages to their summary.
+	summaries map[uint64]string
+}
+
+// maxSummaries bounds the summaries a Summarizer remembers.
+const maxSummaries = 256
+
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+// Fit implements ContextStrategy.
+func (s *Summarizer) Fit(ctx context.Context, body *ChatRequestBody) error {
+	budget, err := promptBudget(*body, s.MaxTokens, s.Models)
+	if err != nil {
+		return err
+	}
+	enc := encodingFor(s.Encoding, body.Model)
+	prompt, err := countPromptTokens(enc, *body)
+	if err != nil || prompt <= budget {
+		return err
+	}
+	keep := max(s.KeepTurns, 1)
+	turns := turnStarts(body.Messages)
+	if len(turns) <= keep {
+		return nil
+	}
+	from := turns[len(turns)-keep]
+	var old []*ChatMessage
+	at := -1
+	for i, m := range body.Messages[:from] {
+		if m.Role != RoleSystem {
+			if at < 0 {
+				at = i
+			}
+			old = append(old, m)
+		}
+	}
+	if len(old) == 0 {
+		return nil
+	}
+	summary, err := s.summarize(ctx, body.Model, old)
+	if err != nil {
+		return fmt.Errorf("summarize: %w", err)
+	}
+	messages := make([]*ChatMessage, 0, len(body.Messages)-len(old)+1)
+	for i, m := range body.Messages {
+		if i == at {
+			messages = append(messages, &ChatMessage{Role: RoleSystem, Content: summaryPrefix + summary})
+		}
+		if i >= from || m.Role == RoleSystem {
+			messages = append(messages, m)
+		}
+	}
+	body.Messages = messages
+	return nil
+}
+
+// summarize returns the summary of messages, extending the summary of their
+// longest prefix summarized before.
+func (s *Summarizer) summarize(ctx context.Context, model string, messages []*ChatMessage) (string, error) {
+	hashes := make([]uint64, len(messages))
+	h := fnv.New64a()
+	for i, m := range messages {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+		hashes[i] = h.Sum64()
+	}
+
+	var previous string
+	done := 0
+	s.mu.Lock()
+	for i := len(hashes) - 1; i >= 0; i-- {
+		if summary, ok := s.summaries[hashes[i]]; ok {
+			previous, done = summary, i+1
+			break
+		}
+	}
+	s.mu.Unlock()
+	if done == len(messages) {
+		return previous, nil
+	}
+
+	var text strings.Builder
+	if previous != "" {
+		fmt.Fprintf(&text, "Previous summary:\n%s\n\n", previous)
+	}
+	writeTranscript(&text, messages[done:])
+	if s.Model != "" {
+		model = s.Model
+	}
+	instructions := s.Prompt
+	if instructions == "" {
+		instructions = DefaultSummaryPrompt
+	}
+	res, err := s.API.CreateChatCompletion(ctx, ChatRequestBody{
+		Model: model,
+		Messages: []*ChatMessage{
+			{Role: RoleSystem, Content: instructions},
+			{Role: RoleUser, Content: text.String()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(res.Choices) == 0 || res.Choices[0].Message == nil {
+		return "", ErrNoChoice
+	}
+	summary := strings.TrimSpace(res.Choices[0].Message.Content)
+
+	s.mu.Lock()
+	if s.summaries == nil || len(s.summaries) >= maxSummaries {
+		s.summaries = make(map[uint64]string)
+	}
+	s.summaries[hashes[len(hashes)-1]] = summary
+	s.mu.Unlock()
+	return summary, nil
+}
+
+// writeTranscript writes messages as text for the model to summarize.
+func writeTranscript(w *strings.Builder, messages []*ChatMessage) {
+	for _, m := range messages {
+		w.WriteString(m.Role)
+		if m.Name != "" {
+			fmt.Fprintf(w, " (%s)", m.Name)
+		}
+		w.WriteString(": ")
+		w.WriteString(m.Content)
+		for _, p := range m.MultiContent {
+			switch p.Type {
+			case ChatMessagePartTypeText:
+				w.WriteString(p.Text)
+			case ChatMessagePartTypeImageURL:
+				w.WriteString("[image]")
+			}
+		}
+		for _, call := range m.ToolCalls {
+			fmt.Fprintf(w, "[called %s(%s)]", call.Function.Name, call.Function.Arguments)
+		}
+		w.WriteString("\n")
+	}
+}
+
+// minCompletionTokens is the completion room kept for a request without
+// MaxTokens to a model without MaxOutputTokens.
+const minCompletionTokens = 1024
+
+// promptBudget returns the tokens a prompt may take: maxTokens, or else the
+// context window of the model less the room of the completion. Without the
+// MaxTokens of the request, that room is the MaxOutputTokens of the model or
+// else minCompletionTokens, at most half the window.
+func promptBudget(body ChatRequestBody, maxTokens int, models *ModelRegistry) (int, error) {
+	if maxTokens > 0 {
+		return maxTokens, nil
+	}
+	if models == nil {
+		models = DefaultModels
+	}
+	info, ok := models.Lookup(body.Model)
+	if !ok || info.ContextWindow == 0 {
+		return 0, fmt.Errorf("%w: context window of %s unknown", ErrInvalidModel, body.Model)
+	}
+	reserve := body.MaxTokens
+	if reserve == 0 {
+		reserve = info.MaxOutputTokens
+		if reserve == 0 {
+			reserve = minCompletionTokens
+		}
+		reserve = min(reserve, info.ContextWindow/2)
+	}
+	return info.ContextWindow - reserve, nil
+}
+
+// encodingFor returns enc, or else the encoding of the model, or else a
+// byteCounter, which overestimates the tokens of models without one.
+func encodingFor(enc *tokenizer.Encoding, model string) tokenCounter {
+	if enc != nil {
+		return enc
+	}
+	return counterFor(model)
+}
+
+// turnStarts returns the indexes of the user messages, which start the turns.
+func turnStarts(messages []*ChatMessage) []int {
+	var turns []int
+	for i, m := range messages {
+		if m.Role == RoleUser {
+			turns = append(turns, i)
+		}
+	}
+	return turns
+}
+
+// dropOldest drops the oldest turns until fits reports true, then the oldest
+// replies of the last turn. A cut falls before a user message or a reply
+// that is not a tool result, so tool calls keep their results.
+func dropOldest(messages []*ChatMessage, fits func([]*ChatMessage) bool) []*ChatMessage {
+	if fits(messages) {
+		return messages
+	}
+	turns := turnStarts(messages)
+	cuts := turns
+	last := -1
+	if len(turns) > 0 {
+		last = turns[len(turns)-1]
+	}
+	for i := last + 1; i < len(messages); i++ {
+		if r := messages[i].Role; r != RoleSystem && r != RoleTool {
+			cuts = append(cuts, i)
+		}
+	}
+	var kept []*ChatMessage
+	for _, from := range cuts {
+		if kept = keepFrom(messages, from); fits(kept) {
+			break
+		}
+	}
+	if kept == nil {
+		return messages
+	}
+	return kept
+}
+
+// keepFrom returns the system messages, the last user message and the
+// messages from index from on.
+func keepFrom(messages []*ChatMessage, from int) []*ChatMessage {
+	last := -1
+	if turns := turnStarts(messages); len(turns) > 0 {
+		last = turns[len(turns)-1]
+	}
+	kept := make([]*ChatMessage, 0, len(messages))
+	for i, m := range messages {
+		if i >= from || i == last || m.Role == RoleSystem {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
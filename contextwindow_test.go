@@ -0,0 +1,172 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// history returns a conversation with system messages, plain turns and a turn
+// calling a tool.
+func history() []*ChatMessage {
+	return []*ChatMessage{
+		{Role: RoleSystem, Content: "sys"},
+		{Role: RoleUser, Content: "u1"},
+		{Role: RoleAssistant, Content: "a1"},
+		{Role: RoleUser, Content: "u2"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "c1", Type: ToolTypeFunction, Function: FunctionCall{Name: "f"}}}},
+		{Role: RoleTool, ToolCallID: "c1", Content: "t1"},
+		{Role: RoleAssistant, Content: "a2"},
+		{Role: RoleSystem, Content: "note"},
+		{Role: RoleUser, Content: "u3"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "c2", Type: ToolTypeFunction, Function: FunctionCall{Name: "f"}}}},
+		{Role: RoleTool, ToolCallID: "c2", Content: "t2"},
+	}
+}
+
+// contents summarizes messages as their contents, or their tool calls.
+func contents(messages []*ChatMessage) string {
+	s := make([]string, len(messages))
+	for i, m := range messages {
+		s[i] = m.Content
+		if len(m.ToolCalls) > 0 {
+			s[i] = "call " + m.ToolCalls[0].ID
+		}
+	}
+	return strings.Join(s, ",")
+}
+
+func TestContextStrategies(t *testing.T) {
+	enc := byteEncoding(t)
+	tests := []struct {
+		name     string
+		strategy ContextStrategy
+		want     string
+	}{
+		{"drop oldest", DropOldest{MaxMessages: 7}, "sys,u2,call c1,t1,a2,note,u3,call c2,t2"},
+		{"drop oldest within the last turn", DropOldest{MaxMessages: 3}, "sys,note,u3,call c2,t2"},
+		{"drop oldest keeps the last reply", DropOldest{MaxMessages: 1}, "sys,note,u3,call c2,t2"},
+		{"drop oldest under the limit", DropOldest{MaxMessages: 9}, contents(history())},
+		{"keep last turns", KeepLastTurns{N: 2}, "sys,u2,call c1,t1,a2,note,u3,call c2,t2"},
+		{"keep more turns than there are", KeepLastTurns{N: 5}, contents(history())},
+		// The history takes 137 tokens, 114 without its first turn.
+		{"token budget", TokenBudget{MaxTokens: 114, Encoding: enc}, "sys,u2,call c1,t1,a2,note,u3,call c2,t2"},
+		{"token budget under the limit", TokenBudget{MaxTokens: 137, Encoding: enc}, contents(history())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := ChatRequestBody{Model: GPT4, Messages: history()}
+			if err := tt.strategy.Fit(context.Background(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if got := contents(body.Messages); got != tt.want {
+				t.Errorf("Fit() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	body := ChatRequestBody{Model: GPT4, Messages: history()}
+	if err := (TokenBudget{MaxTokens: 20, Encoding: enc}).Fit(context.Background(), &body); !errors.Is(err, ErrContextWindowExceeded) {
+		t.Errorf("Fit() over budget = %v, want ErrContextWindowExceeded", err)
+	}
+	// Models without a known encoding are counted a token per byte.
+	body = ChatRequestBody{Model: "custom", Messages: history()}
+	if err := (TokenBudget{MaxTokens: 114}).Fit(context.Background(), &body); err != nil ||
+		contents(body.Messages) != "sys,u2,call c1,t1,a2,note,u3,call c2,t2" {
+		t.Errorf("Fit() of an unknown model = %s, %v", contents(body.Messages), err)
+	}
+	body = ChatRequestBody{Model: GPT4, MaxTokens: 8192 - 60, Messages: history()}
+	if err := (TokenBudget{Encoding: enc}).Fit(context.Background(), &body); !errors.Is(err, ErrContextWindowExceeded) {
+		t.Errorf("Fit() over the context window = %v, want ErrContextWindowExceeded", err)
+	}
+}
+
+func TestPromptBudget(t *testing.T) {
+	models := NewModelRegistry(
+		ModelInfo{ID: "window-only", ContextWindow: 8192},
+		ModelInfo{ID: "max-output", ContextWindow: 128000, MaxOutputTokens: 16384},
+		ModelInfo{ID: "small", ContextWindow: 1000},
+	)
+	tests := []struct {
+		model               string
+		maxTokens, reserved int
+		want                int
+	}{
+		{"window-only", 500, 0, 500},
+		{"window-only", 0, 192, 8000},
+		{"window-only", 0, 0, 8192 - minCompletionTokens},
+		{"max-output", 0, 0, 128000 - 16384},
+		{"small", 0, 0, 500},
+	}
+	for _, tt := range tests {
+		got, err := promptBudget(ChatRequestBody{Model: tt.model, MaxTokens: tt.reserved}, tt.maxTokens, models)
+		if err != nil || got != tt.want {
+			t.Errorf("promptBudget(%s, %d, %d) = %d, %v, want %d", tt.model, tt.maxTokens, tt.reserved, got, err, tt.want)
+		}
+	}
+	if _, err := promptBudget(ChatRequestBody{Model: "custom"}, 0, models); !errors.Is(err, ErrInvalidModel) {
+		t.Errorf("promptBudget of an unknown model = %v, want ErrInvalidModel", err)
+	}
+}
+
+func TestSummarizer(t *testing.T) {
+	var requests []string
+	s := &Summarizer{
+		API: chatFunc(func(_ context.Context, body ChatRequestBody) (*ChatResponseBody, error) {
+			requests = append(requests, body.Messages[1].Content)
+			summary := "summary " + string(rune('0'+len(requests)))
+			return &ChatResponseBody{Choices: []*ChatChoice{{Message: &ChatMessage{Role: RoleAssistant, Content: summary}}}}, nil
+		}),
+		MaxTokens: 60,
+		Encoding:  byteEncoding(t),
+	}
+	ctx := context.Background()
+	body := ChatRequestBody{Model: GPT4, Messages: history()}
+	if err := s.Fit(ctx, &body); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(body.Messages), "sys,"+summaryPrefix+"summary 1,note,u3,call c2,t2"; got != want {
+		t.Errorf("Fit() = %s, want %s", got, want)
+	}
+	if want := "user: u1\nassistant: a1\nuser: u2\nassistant: [called f()]\ntool: t1\nassistant: a2\n"; requests[0] != want {
+		t.Errorf("summarized %q, want %q", requests[0], want)
+	}
+
+	// The same history reuses the summary, a longer one extends it.
+	body = ChatRequestBody{Model: GPT4, Messages: history()}
+	if err := s.Fit(ctx, &body); err != nil || len(requests) != 1 {
+		t.Fatalf("Fit() again = %v, %d summaries", err, len(requests))
+	}
+	body.Messages = append(history(),
+		&ChatMessage{Role: RoleAssistant, Content: "a3"},
+		&ChatMessage{Role: RoleUser, Content: "u4"})
+	if err := s.Fit(ctx, &body); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := contents(body.Messages), "sys,"+summaryPrefix+"summary 2,note,u4"; got != want {
+		t.Errorf("Fit() = %s, want %s", got, want)
+	}
+	if want := "Previous summary:\nsummary 1\n\nuser: u3\nassistant: [called f()]\ntool: t2\nassistant: a3\n"; requests[1] != want {
+		t.Errorf("summarized %q, want %q", requests[1], want)
+	}
+}
+
+func TestConversation_context(t *testing.T) {
+	var sent []*ChatMessage
+	conv := NewConversation(chatFunc(func(ctx context.Context, body ChatRequestBody) (*ChatResponseBody, error) {
+		sent = body.Messages
+		return echoChat(ctx, body)
+	}), ConversationOptions{Model: GPT4o, SystemPrompt: "sys", Context: []ContextStrategy{KeepLastTurns{N: 1}}})
+	for _, text := range []string{"u1", "u2"} {
+		if _, err := conv.Send(context.Background(), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := contents(sent); got != "sys,u2" {
+		t.Errorf("sent %s, want sys,u2", got)
+	}
+	if got := len(conv.Messages()); got != 4 {
+		t.Errorf("%d messages in the history, want 4", got)
+	}
+}
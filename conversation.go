@@ -0,0 +1,333 @@
+package openai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrNoChoice is returned when a chat completion has no choice to reply
+	// with.
+	ErrNoChoice = errors.New("chat completion has no choice")
+	// ErrIncompleteStream is returned when a stream ends before the reply
+	// has a finish reason, e.g. because the connection was lost.
+	ErrIncompleteStream = errors.New("chat completion stream ended without a finish reason")
+)
+
+// ConversationOptions configures a Conversation.
+type ConversationOptions struct {
+	// ID identifies the conversation in a ConversationStore, a random one
+	// when "".
+	ID    string
+	Model string
+	// SystemPrompt is sent as the first message of every request, "" for
+	// none.
+	SystemPrompt string
+	// Request is the template of the requests, e.g. to set Temperature.
+	// Its Model, Messages and Stream are replaced.
+	Request ChatRequestBody
+	// Context shortens the messages of every request, in order, e.g. to drop
+	// the oldest turns once they no longer fit in the context window. The
+	// history keeps every message.
+	Context []ContextStrategy
+}
+
+// Conversation keeps the message history of a chat, sending it with every
+// new message and appending the replies. Turns are taken one at a time; a
+// Conversation is safe for concurrent use.
+type Conversation struct {
+	api        ChatAPI
+	request    ChatRequestBody
+	strategies []ContextStrategy
+
+	// turn serializes the turns, mu guards the state so that it can be read
+	// while a turn waits for its reply.
+	turn         sync.Mutex
+	mu           sync.RWMutex
+	id           string
+	model        string
+	systemPrompt string
+	messages     []*ChatMessage
+	usage        TokensUsage
+}
+
+// NewConversation returns an empty Conversation sending its requests to api,
+// a *Client or a fake.
+func NewConversation(api ChatAPI, opts ConversationOptions) *Conversation {
+	id := opts.ID
+	if id == "" {
+		id = newConversationID()
+	}
+	return &Conversation{
+		id:           id,
+		api:          api,
+		request:      opts.Request,
+		strategies:   opts.Context,
+		model:        opts.Model,
+		systemPrompt: opts.SystemPrompt,
+	}
+}
+
+// Send sends a user message and returns the reply of the assistant, adding
+// both to the history. The history is left unchanged on error.
+func (c *Conversation) Send(ctx context.Context, text string, opts ...RequestOption) (*ChatMessage, error) {
+	return c.send(ctx, &ChatMessage{Role: RoleUser, Content: text}, nil, opts)
+}
+
+// SendStream is like Send but streams the reply, calling onDelta with every
+// piece of its content as it is received.
+func (c *Conversation) SendStream(ctx context.Context,
+	text string,
+	onDelta func(delta string),
+	opts ...RequestOption) (*ChatMessage, error) {
+	if onDelta == nil {
+		onDelta = func(string) {}
+	}
+	return c.send(ctx, &ChatMessage{Role: RoleUser, Content: text}, onDelta, opts)
+}
+
+// Reply asks the assistant to reply to the history as it is, e.g. after
+// appending the results of the tools it called.
+func (c *Conversation) Reply(ctx context.Context, opts ...RequestOption) (*ChatMessage, error) {
+	return c.send(ctx, nil, nil, opts)
+}
+
+// Append adds copies of messages to the history without sending them. Nil
+// messages are skipped.
+func (c *Conversation) Append(messages ...*ChatMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, copyMessages(messages)...)
+}
+
+// Messages returns a copy of the history, without the system prompt.
+func (c *Conversation) Messages() []*ChatMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyMessages(c.messages)
+}
+
+// Usage returns the tokens used by the conversation so far.
+func (c *Conversation) Usage() TokensUsage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.usage
+}
+
+// ID returns the ID of the conversation.
+func (c *Conversation) ID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.id
+}
+
+// Model returns the model of the conversation.
+func (c *Conversation) Model() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.model
+}
+
+// Reset clears the history and usage, keeping the system prompt.
+func (c *Conversation) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages, c.usage = nil, TokensUsage{}
+}
+
+// send takes a turn: it sends the history followed by message, if any, and
+// appends both to the history once the reply is received.
+func (c *Conversation) send(ctx context.Context,
+	message *ChatMessage,
+	onDelta func(string),
+	opts []RequestOption) (*ChatMessage, error) {
+	c.turn.Lock()
+	defer c.turn.Unlock()
+
+	c.mu.RLock()
+	body := c.request
+	body.Model = c.model
+	body.Messages = make([]*ChatMessage, 0, len(c.messages)+2)
+	if c.systemPrompt != "" {
+		body.Messages = append(body.Messages, &ChatMessage{Role: RoleSystem, Content: c.systemPrompt})
+	}
+	body.Messages = append(body.Messages, c.messages...)
+	c.mu.RUnlock()
+	if message != nil {
+		body.Messages = append(body.Messages, message)
+	}
+	for _, s := range c.strategies {
+		if err := s.Fit(ctx, &body); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		reply *ChatMessage
+		usage TokensUsage
+		err   error
+	)
+	if onDelta != nil {
+		reply, usage, err = c.stream(ctx, body, onDelta, opts)
+	} else {
+		reply, usage, err = c.complete(ctx, body, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if message != nil {
+		c.messages = append(c.messages, message)
+	}
+	c.messages = append(c.messages, reply)
+	c.usage.PromptTokens += usage.PromptTokens
+	c.usage.CompletionTokens += usage.CompletionTokens
+	c.usage.TotalTokens += usage.TotalTokens
+	copied := *reply
+	return &copied, nil
+}
+
+func (c *Conversation) complete(ctx context.Context,
+	body ChatRequestBody,
+	opts []RequestOption) (*ChatMessage, TokensUsage, error) {
+	body.Stream, body.StreamOptions = false, nil
+	res, err := c.api.CreateChatCompletion(ctx, body, opts...)
+	if err != nil {
+		return nil, TokensUsage{}, err
+	}
+	if len(res.Choices) == 0 || res.Choices[0].Message == nil {
+		return nil, TokensUsage{}, ErrNoChoice
+	}
+	return res.Choices[0].Message, res.Usage, nil
+}
+
+func (c *Conversation) stream(ctx context.Context,
+	body ChatRequestBody,
+	onDelta func(string),
+	opts []RequestOption) (*ChatMessage, TokensUsage, error) {
+	body.Stream, body.StreamOptions = true, &StreamOptions{IncludeUsage: true}
+	res, err := c.api.CreateChatCompletion(ctx, body, opts...)
+	if err != nil {
+		return nil, TokensUsage{}, err
+	}
+	var acc StreamAccumulator
+	for chunk := range res.StreamChan {
+		acc.Add(chunk)
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.Delta != nil && choice.Delta.Content != "" {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, TokensUsage{}, err
+	}
+	res = acc.Response()
+	if len(res.Choices) == 0 || res.Choices[0].Index != 0 {
+		return nil, TokensUsage{}, ErrNoChoice
+	}
+	if res.Choices[0].FinishReason == nil {
+		return nil, TokensUsage{}, ErrIncompleteStream
+	}
+	return res.Choices[0].Message, res.Usage, nil
+}
+
+// ConversationState is the state of a Conversation, as it is encoded in JSON
+// and kept by a ConversationStore.
+type ConversationState struct {
+	ID           string         `json:"id"`
+	Model        string         `json:"model"`
+	SystemPrompt string         `json:"system_prompt,omitempty"`
+	Messages     []*ChatMessage `json:"messages"`
+	Usage        TokensUsage    `json:"usage"`
+}
+
+// State returns the state of the conversation, with a copy of its history.
+func (c *Conversation) State() ConversationState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return ConversationState{
+		ID:           c.id,
+		Model:        c.model,
+		SystemPrompt: c.systemPrompt,
+		Messages:     copyMessages(c.messages),
+		Usage:        c.usage,
+	}
+}
+
+// Restore replaces the state of the conversation, e.g. with one loaded from
+// a ConversationStore. The ID is kept when the state has none.
+func (c *Conversation) Restore(s ConversationState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.ID != "" {
+		c.id = s.ID
+	}
+	c.model, c.systemPrompt = s.Model, s.SystemPrompt
+	c.messages, c.usage = copyMessages(s.Messages), s.Usage
+}
+
+// MarshalJSON implements json.Marshaler, encoding the ConversationState.
+func (c *Conversation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.State())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, restoring the state encoded by
+// MarshalJSON into a Conversation returned by NewConversation.
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var s ConversationState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	c.Restore(s)
+	return nil
+}
+
+func newConversationID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// copyMessages returns deep copies of the messages, skipping nil ones.
+func copyMessages(messages []*ChatMessage) []*ChatMessage {
+	copied := make([]*ChatMessage, 0, len(messages))
+	for _, m := range messages {
+		if m != nil {
+			copied = append(copied, copyMessage(m))
+		}
+	}
+	return copied
+}
+
+// copyMessage returns a deep copy of a message that is not nil.
+func copyMessage(m *ChatMessage) *ChatMessage {
+	c := *m
+	if m.MultiContent != nil {
+		c.MultiContent = make([]ChatMessagePart, len(m.MultiContent))
+		for i, p := range m.MultiContent {
+			if p.ImageURL != nil {
+				u := *p.ImageURL
+				p.ImageURL = &u
+			}
+			c.MultiContent[i] = p
+		}
+	}
+	if m.ToolCalls != nil {
+		c.ToolCalls = make([]ToolCall, len(m.ToolCalls))
+		for i, tc := range m.ToolCalls {
+			if tc.Index != nil {
+				index := *tc.Index
+				tc.Index = &index
+			}
+			c.ToolCalls[i] = tc
+		}
+	}
+	return &c
+}
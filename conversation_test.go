@@ -0,0 +1,181 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// chatFunc adapts a function to ChatAPI.
+type chatFunc func(ctx context.Context, body ChatRequestBody) (*ChatResponseBody, error)
+
+func (f chatFunc) CreateChatCompletion(ctx context.Context, body ChatRequestBody, _ ...RequestOption) (*ChatResponseBody, error) {
+	return f(ctx, body)
+}
+
+// echoChat replies with the number of messages it received and the content
+// of the last one, streamed word by word when asked to.
+func echoChat(_ context.Context, body ChatRequestBody) (*ChatResponseBody, error) {
+	last := body.Messages[len(body.Messages)-1]
+	content := fmt.Sprintf("%d: %s", len(body.Messages), last.Content)
+	usage := TokensUsage{PromptTokens: len(body.Messages), CompletionTokens: 1, TotalTokens: len(body.Messages) + 1}
+	if !body.Stream {
+		return &ChatResponseBody{
+			Choices: []*ChatChoice{{Message: &ChatMessage{Role: RoleAssistant, Content: content}}},
+			Usage:   usage,
+		}, nil
+	}
+	ch := make(chan *ChatStreamChunk, 16)
+	ch <- &ChatStreamChunk{Choices: []*ChatChoice{{Delta: &ChatMessage{Role: RoleAssistant}}}}
+	for _, word := range strings.SplitAfter(content, " ") {
+		ch <- &ChatStreamChunk{Choices: []*ChatChoice{{Delta: &ChatMessage{Content: word}}}}
+	}
+	stop := "stop"
+	ch <- &ChatStreamChunk{Choices: []*ChatChoice{{Delta: &ChatMessage{}, FinishReason: &stop}}}
+	if body.StreamOptions != nil && body.StreamOptions.IncludeUsage {
+		ch <- &ChatStreamChunk{Usage: &usage}
+	}
+	close(ch)
+	return &ChatResponseBody{StreamChan: ch}, nil
+}
+
+func TestConversation(t *testing.T) {
+	var requests []ChatRequestBody
+	conv := NewConversation(chatFunc(func(ctx context.Context, body ChatRequestBody) (*ChatResponseBody, error) {
+		requests = append(requests, body)
+		return echoChat(ctx, body)
+	}), ConversationOptions{
+		Model:        GPT4o,
+		SystemPrompt: "Be brief.",
+		Request:      ChatRequestBody{Temperature: 0.5},
+	})
+	ctx := context.Background()
+
+	reply, err := conv.Send(ctx, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Role != RoleAssistant || reply.Content != "2: hello" {
+		t.Errorf("Send() = %+v", reply)
+	}
+	var deltas []string
+	reply, err = conv.SendStream(ctx, "how are you", func(delta string) { deltas = append(deltas, delta) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Content != "4: how are you" || len(deltas) != 4 {
+		t.Errorf("SendStream() = %+v, deltas %q", reply, deltas)
+	}
+
+	want := []*ChatMessage{
+		{Role: RoleUser, Content: "hello"},
+		{Role: RoleAssistant, Content: "2: hello"},
+		{Role: RoleUser, Content: "how are you"},
+		{Role: RoleAssistant, Content: "4: how are you"},
+	}
+	if got := conv.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages() = %+v", got)
+	}
+	if got := conv.Usage(); got != (TokensUsage{PromptTokens: 6, CompletionTokens: 2, TotalTokens: 8}) {
+		t.Errorf("Usage() = %+v", got)
+	}
+	last := requests[len(requests)-1]
+	if last.Model != GPT4o || last.Temperature != 0.5 || !last.Stream ||
+		last.Messages[0].Role != RoleSystem || last.Messages[0].Content != "Be brief." {
+		t.Errorf("request = %+v", last)
+	}
+
+	// A failed turn leaves the history unchanged.
+	failing := NewConversation(chatFunc(func(context.Context, ChatRequestBody) (*ChatResponseBody, error) {
+		return nil, errors.New("unavailable")
+	}), ConversationOptions{Model: GPT4o})
+	if _, err = failing.Send(ctx, "hello"); err == nil || len(failing.Messages()) != 0 {
+		t.Errorf("failed Send() = %v, history %+v", err, failing.Messages())
+	}
+
+	data, err := json.Marshal(conv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewConversation(chatFunc(echoChat), ConversationOptions{})
+	if err = json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Model() != GPT4o || !reflect.DeepEqual(restored.Messages(), want) || restored.Usage() != conv.Usage() {
+		t.Errorf("restored conversation = %s", data)
+	}
+	if reply, err = restored.Send(ctx, "bye"); err != nil || reply.Content != "6: bye" {
+		t.Errorf("Send() after restore = %+v, %v", reply, err)
+	}
+
+	// Nil messages are skipped.
+	if err = json.Unmarshal([]byte(`{"model":"gpt-4o","messages":[null,{"role":"user","content":"hi"}]}`), restored); err != nil {
+		t.Fatal(err)
+	}
+	restored.Append(nil)
+	if got := restored.Messages(); len(got) != 1 || got[0].Content != "hi" {
+		t.Errorf("Messages() with nil messages = %+v", got)
+	}
+}
+
+func TestConversation_incompleteStream(t *testing.T) {
+	conv := NewConversation(chatFunc(func(context.Context, ChatRequestBody) (*ChatResponseBody, error) {
+		ch := make(chan *ChatStreamChunk, 1)
+		ch <- &ChatStreamChunk{Choices: []*ChatChoice{{Delta: &ChatMessage{Role: RoleAssistant, Content: "Hel"}}}}
+		close(ch)
+		return &ChatResponseBody{StreamChan: ch}, nil
+	}), ConversationOptions{Model: GPT4o})
+	if _, err := conv.SendStream(context.Background(), "hello", func(string) {}); !errors.Is(err, ErrIncompleteStream) {
+		t.Errorf("SendStream() = %v, want ErrIncompleteStream", err)
+	}
+	if len(conv.Messages()) != 0 {
+		t.Errorf("history = %+v after an incomplete stream", conv.Messages())
+	}
+}
+
+func TestCopyMessages(t *testing.T) {
+	index := 0
+	messages := []*ChatMessage{{
+		Role:         RoleAssistant,
+		MultiContent: []ChatMessagePart{{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: "a"}}},
+		ToolCalls:    []ToolCall{{Index: &index, ID: "c1"}},
+	}}
+	copied := copyMessages(messages)
+	messages[0].MultiContent[0].ImageURL.URL = "b"
+	messages[0].ToolCalls[0].ID = "c2"
+	*messages[0].ToolCalls[0].Index = 1
+	c := copied[0]
+	if c.MultiContent[0].ImageURL.URL != "a" || c.ToolCalls[0].ID != "c1" || *c.ToolCalls[0].Index != 0 {
+		t.Errorf("copy changed with the original: %+v", c)
+	}
+}
+
+func TestConversation_concurrent(t *testing.T) {
+	conv := NewConversation(chatFunc(echoChat), ConversationOptions{Model: GPT4o})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := conv.Send(context.Background(), "hi"); err != nil {
+				t.Error(err)
+			}
+			_ = conv.Messages()
+		}()
+	}
+	wg.Wait()
+	messages := conv.Messages()
+	if len(messages) != 20 {
+		t.Fatalf("%d messages, want 20", len(messages))
+	}
+	for i := 1; i < len(messages); i += 2 {
+		if want := fmt.Sprintf("%d: hi", i); messages[i].Content != want {
+			t.Errorf("messages[%d] = %q, want %q", i, messages[i].Content, want)
+		}
+	}
+}
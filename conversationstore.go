@@ -0,0 +1,346 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrConversationNotFound is returned by a ConversationStore for an ID it
+// does not hold.
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationStore keeps conversations by ID, e.g. so that a web handler can
+// load a conversation, send a message and store the result:
+//
+//	state, err := store.Load(ctx, id)
+//	...
+//	conv := openai.NewConversation(client, openai.ConversationOptions{})
+//	conv.Restore(state)
+//	reply, err := conv.Send(ctx, text)
+//	...
+//	err = store.Save(ctx, conv.State())
+type ConversationStore interface {
+	// Save stores a conversation, replacing the one with the same ID. Nil
+	// messages are skipped.
+	Save(ctx context.Context, state ConversationState) error
+	// Load returns the conversation with an ID, or ErrConversationNotFound.
+	Load(ctx context.Context, id string) (ConversationState, error)
+	// List returns the IDs of the stored conversations, sorted.
+	List(ctx context.Context) ([]string, error)
+	// Delete removes a conversation. Deleting a conversation that is not
+	// stored is not an error.
+	Delete(ctx context.Context, id string) error
+	// AppendMessages adds messages to the history of a stored conversation,
+	// or returns ErrConversationNotFound. Its usage is left as it was saved.
+	// Nil messages are skipped.
+	AppendMessages(ctx context.Context, id string, messages ...*ChatMessage) error
+}
+
+// MemoryConversationStore is a ConversationStore keeping the conversations in
+// memory.
+type MemoryConversationStore struct {
+	mu            sync.Mutex
+	conversations map[string]ConversationState
+}
+
+// NewMemoryConversationStore returns an empty MemoryConversationStore.
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{conversations: make(map[string]ConversationState)}
+}
+
+// Save implements ConversationStore.
+func (m *MemoryConversationStore) Save(_ context.Context, state ConversationState) error {
+	state.Messages = copyMessages(state.Messages)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conversations[state.ID] = state
+	return nil
+}
+
+// Load implements ConversationStore.
+func (m *MemoryConversationStore) Load(_ context.Context, id string) (ConversationState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.conversations[id]
+	if !ok {
+		return ConversationState{}, fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	}
+	state.Messages = copyMessages(state.Messages)
+	return state, nil
+}
+
+// List implements ConversationStore.
+func (m *MemoryConversationStore) List(context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.conversations))
+	for id := range m.conversations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete implements ConversationStore.
+func (m *MemoryConversationStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conversations, id)
+	return nil
+}
+
+// AppendMessages implements ConversationStore.
+func (m *MemoryConversationStore) AppendMessages(_ context.Context, id string, messages ...*ChatMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.conversations[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	}
+	state.Messages = append(state.Messages, copyMessages(messages)...)
+	m.conversations[id] = state
+	return nil
+}
+
+// FileConversationStore is a ConversationStore keeping every conversation in
+// a JSON Lines file of a directory: a first line with its model, system
+// prompt and usage, followed by a line per message. Saves replace the file
+// atomically and appends add lines to it, so that a crash loses at most the
+// message being appended. IDs may contain letters, digits, '.', '-' and '_'.
+type FileConversationStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// conversationHeader is the first line of a conversation file.
+type conversationHeader struct {
+	ID           string      `json:"id"`
+	Model        string      `json:"model"`
+	SystemPrompt string      `json:"system_prompt,omitempty"`
+	Usage        TokensUsage `json:"usage"`
+}
+
+const conversationExt = ".jsonl"
+
+// NewFileConversationStore returns a FileConversationStore keeping the
+// conversations in dir, which is created if needed.
+func NewFileConversationStore(dir string) (*FileConversationStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileConversationStore{dir: dir}, nil
+}
+
+// Save implements ConversationStore.
+func (f *FileConversationStore) Save(_ context.Context, state ConversationState) error {
+	path, err := f.path(state.ID)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(conversationHeader{state.ID, state.Model, state.SystemPrompt, state.Usage}); err != nil {
+		return err
+	}
+	for _, m := range state.Messages {
+		if m == nil {
+			continue
+		}
+		if err = enc.Encode(m); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tmp, err := os.CreateTemp(f.dir, state.ID+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(buf.Bytes()); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Load implements ConversationStore.
+func (f *FileConversationStore) Load(_ context.Context, id string) (ConversationState, error) {
+	path, err := f.path(id)
+	if err != nil {
+		return ConversationState{}, err
+	}
+	f.mu.Lock()
+	file, err := os.Open(path)
+	f.mu.Unlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return ConversationState{}, fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	} else if err != nil {
+		return ConversationState{}, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var header conversationHeader
+	line, err := r.ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &header)
+	}
+	if err != nil {
+		return ConversationState{}, fmt.Errorf("conversation %s: %w", id, err)
+	}
+	state := ConversationState{
+		ID:           header.ID,
+		Model:        header.Model,
+		SystemPrompt: header.SystemPrompt,
+		Messages:     []*ChatMessage{},
+		Usage:        header.Usage,
+	}
+	for {
+		line, err = r.ReadBytes('\n')
+		if err == io.EOF {
+			// A last line without a newline is an interrupted append.
+			return state, nil
+		} else if err != nil {
+			return ConversationState{}, fmt.Errorf("conversation %s: %w", id, err)
+		}
+		var m *ChatMessage
+		if err = json.Unmarshal(line, &m); err != nil {
+			return ConversationState{}, fmt.Errorf("conversation %s: message %d: %w", id, len(state.Messages), err)
+		}
+		if m != nil {
+			state.Messages = append(state.Messages, m)
+		}
+	}
+}
+
+// List implements ConversationStore.
+func (f *FileConversationStore) List(context.Context) ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), conversationExt); ok && e.Type().IsRegular() && validConversationID(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete implements ConversationStore.
+func (f *FileConversationStore) Delete(_ context.Context, id string) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err = os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// AppendMessages implements ConversationStore.
+func (f *FileConversationStore) AppendMessages(_ context.Context, id string, messages ...*ChatMessage) error {
+	path, err := f.path(id)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	for _, m := range messages {
+		if m == nil {
+			continue
+		}
+		if err = enc.Encode(m); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	end, err := completeLines(file)
+	if err == nil {
+		_, err = file.WriteAt(buf.Bytes(), end)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	return err
+}
+
+// completeLines returns the size of a file without the partial line an
+// interrupted append may have left at its end, truncating it.
+func completeLines(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return 0, nil
+	}
+	last := make([]byte, 1)
+	if _, err = file.ReadAt(last, size-1); err != nil {
+		return 0, err
+	}
+	if last[0] == '\n' {
+		return size, nil
+	}
+	data := make([]byte, size)
+	if _, err = file.ReadAt(data, 0); err != nil {
+		return 0, err
+	}
+	end := int64(bytes.LastIndexByte(data, '\n') + 1)
+	return end, file.Truncate(end)
+}
+
+func (f *FileConversationStore) path(id string) (string, error) {
+	if !validConversationID(id) {
+		return "", fmt.Errorf("invalid conversation ID %q", id)
+	}
+	return filepath.Join(f.dir, id+conversationExt), nil
+}
+
+func validConversationID(id string) bool {
+	if id == "" || len(id) > 128 || id[0] == '.' {
+		return false
+	}
+	for _, r := range id {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
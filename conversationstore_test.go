@@ -0,0 +1,124 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConversationStores(t *testing.T) {
+	fileStore, err := NewFileConversationStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]ConversationStore{
+		"memory": NewMemoryConversationStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			conv := NewConversation(chatFunc(echoChat), ConversationOptions{ID: "chat-1", Model: GPT4o, SystemPrompt: "<sys>"})
+			if _, err := conv.Send(ctx, "hello"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save(ctx, conv.State()); err != nil {
+				t.Fatal(err)
+			}
+			// Nil messages are skipped.
+			hi := &ChatMessage{Role: RoleUser, Content: "hi"}
+			if err := store.Save(ctx, ConversationState{ID: "chat-0", Model: GPT4, Messages: []*ChatMessage{nil, hi}}); err != nil {
+				t.Fatal(err)
+			}
+			if state, err := store.Load(ctx, "chat-0"); err != nil || !reflect.DeepEqual(state.Messages, []*ChatMessage{hi}) {
+				t.Errorf("Load() of a conversation saved with a nil message = %+v, %v", state.Messages, err)
+			}
+			if ids, err := store.List(ctx); err != nil || !reflect.DeepEqual(ids, []string{"chat-0", "chat-1"}) {
+				t.Errorf("List() = %q, %v", ids, err)
+			}
+
+			state, err := store.Load(ctx, "chat-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state, conv.State()) {
+				t.Errorf("Load() = %+v, want %+v", state, conv.State())
+			}
+			restored := NewConversation(chatFunc(echoChat), ConversationOptions{})
+			restored.Restore(state)
+			reply, err := restored.Send(ctx, "again")
+			if err != nil || reply.Content != "4: again" {
+				t.Fatalf("Send() after Load = %+v, %v", reply, err)
+			}
+
+			user := &ChatMessage{Role: RoleUser, Content: "again"}
+			if err = store.AppendMessages(ctx, "chat-1", user, nil, reply); err != nil {
+				t.Fatal(err)
+			}
+			if state, err = store.Load(ctx, "chat-1"); err != nil || !reflect.DeepEqual(state.Messages, restored.Messages()) {
+				t.Errorf("Load() after AppendMessages = %+v, %v", state.Messages, err)
+			}
+
+			if err = store.Delete(ctx, "chat-1"); err != nil {
+				t.Fatal(err)
+			}
+			if err = store.Delete(ctx, "chat-1"); err != nil {
+				t.Errorf("Delete() again = %v", err)
+			}
+			if _, err = store.Load(ctx, "chat-1"); !errors.Is(err, ErrConversationNotFound) {
+				t.Errorf("Load() after Delete = %v, want ErrConversationNotFound", err)
+			}
+			if err = store.AppendMessages(ctx, "chat-1", user); !errors.Is(err, ErrConversationNotFound) {
+				t.Errorf("AppendMessages() after Delete = %v, want ErrConversationNotFound", err)
+			}
+		})
+	}
+}
+
+func TestFileConversationStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileConversationStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"", "../escape", ".hidden", "a/b"} {
+		if err = store.Save(ctx, ConversationState{ID: id}); err == nil {
+			t.Errorf("Save(%q) succeeded", id)
+		}
+	}
+
+	messages := []*ChatMessage{{Role: RoleUser, Content: "hi"}}
+	if err = store.Save(ctx, ConversationState{ID: "c", Model: GPT4, Messages: messages}); err != nil {
+		t.Fatal(err)
+	}
+	// An append interrupted by a crash leaves a partial line, which is
+	// ignored, then replaced by the next append. The null lines of nil
+	// messages are ignored too.
+	path := filepath.Join(dir, "c.jsonl")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.WriteString("null\n" + `{"role":"assis`)
+	_ = file.Close()
+
+	store, _ = NewFileConversationStore(dir)
+	state, err := store.Load(ctx, "c")
+	if err != nil || !reflect.DeepEqual(state.Messages, messages) {
+		t.Fatalf("Load() = %+v, %v", state.Messages, err)
+	}
+	reply := &ChatMessage{Role: RoleAssistant, Content: "hello"}
+	if err = store.AppendMessages(ctx, "c", reply); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = store.Load(ctx, "c"); err != nil || !reflect.DeepEqual(state.Messages, append(messages, reply)) {
+		t.Errorf("Load() after AppendMessages = %+v, %v", state.Messages, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want 1", len(entries))
+	}
+}
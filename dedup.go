@@ -0,0 +1,105 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// DedupMiddleware returns a Middleware that coalesces concurrent identical
+// requests into a single HTTP call and hands its decoded result to every
+// caller. It applies to reads, such as RetrieveModel and ListFiles, and to
+// deterministic requests as defined by CacheMiddleware, never to streams.
+//
+// The shared call is not bound to the context of the caller that started
+// it: it keeps running while any caller still waits for it, and is only
+// canceled once all of them have given up.
+func DedupMiddleware() Middleware {
+	g := &flightGroup{flights: make(map[string]*flight)}
+	return func(next Handler) Handler {
+		return func(call *Call) error {
+			if call.Stream() || call.Result == nil ||
+				(call.Request.Method != http.MethodGet && !deterministic(call)) {
+				return next(call)
+			}
+			key, err := cacheKey(call)
+			if err != nil {
+				return next(call)
+			}
+			return g.do(key, call, next)
+		}
+	}
+}
+
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a call shared by all its waiters.
+type flight struct {
+	done     chan struct{}
+	waiters  int
+	cancel   context.CancelFunc
+	result   []byte
+	response *http.Response
+	err      error
+}
+
+func (g *flightGroup) do(key string, call *Call, next Handler) error {
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(call.Context()))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		shared := *call
+		shared.Request = call.Request.WithContext(ctx)
+		shared.Result = reflect.New(reflect.TypeOf(call.Result).Elem()).Interface()
+		go g.run(key, f, &shared, next)
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-call.Context().Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody waits anymore: abandon the call, and let new callers
+			// start a fresh one rather than join a canceled one.
+			f.cancel()
+			g.forget(key, f)
+		}
+		g.mu.Unlock()
+		return call.Context().Err()
+	}
+
+	call.Response = f.response
+	if f.err != nil {
+		return f.err
+	}
+	return decodeResult(f.result, call.Result)
+}
+
+func (g *flightGroup) run(key string, f *flight, call *Call, next Handler) {
+	defer f.cancel()
+	f.err = next(call)
+	f.response = call.Response
+	if f.err == nil {
+		f.result, f.err = encodeResult(call.Result)
+	}
+	g.mu.Lock()
+	g.forget(key, f)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// forget removes f from the group unless it was already replaced.
+func (g *flightGroup) forget(key string, f *flight) {
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedupMiddleware(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"gpt-3.5-turbo","object":"model","owned_by":"openai"}`))
+	})
+	c.Use(DedupMiddleware())
+
+	const callers = 5
+	var wg sync.WaitGroup
+	models := make([]ModelObject, callers)
+	errs := make([]error, callers)
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	for i := 0; i < callers; i++ {
+		ctx := context.Background()
+		if i == 0 {
+			ctx = leaderCtx
+		}
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			models[i], errs[i] = c.RetrieveModel(ctx, GPT35Turbo)
+		}(i, ctx)
+		if i == 0 {
+			// Let the leader start the shared call before the others join.
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hit %d times, want 1", got)
+	}
+	if !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("leader error = %v, want %v", errs[0], context.Canceled)
+	}
+	for i := 1; i < callers; i++ {
+		if errs[i] != nil || models[i].ID != GPT35Turbo {
+			t.Errorf("caller %d got %+v, %v", i, models[i], errs[i])
+		}
+	}
+}
+
+func TestDedupMiddleware_AllCanceled(t *testing.T) {
+	canceled := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(canceled)
+	})
+	c.Use(DedupMiddleware())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := c.ListModels(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("shared request was not canceled once nobody waited for it")
+	}
+}
@@ -2,7 +2,6 @@ package openai
 
 import (
 	"context"
-	"errors"
 	"net/http"
 )
 
@@ -17,6 +16,26 @@ type EditRequestBody struct {
 	N           int     `json:"n,omitempty"`
 	Temperature float32 `json:"temperature,omitempty"`
 	TopP        float32 `json:"top_p,omitempty"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b EditRequestBody) MarshalJSON() ([]byte, error) {
+	type plain EditRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b EditRequestBody) Validate() error {
+	var v validation
+	v.required("model", b.Model)
+	v.required("instruction", b.Instruction)
+	v.intRange("n", b.N, 1, 20)
+	v.floatRange("temperature", b.Temperature, 0, 2)
+	v.floatRange("top_p", b.TopP, 0, 1)
+	return v.err()
 }
 
 type EditChoice struct {
@@ -29,33 +48,18 @@ type EditResponseBody struct {
 	Object  string       `json:"object"`
 	Created int          `json:"created"`
 	Choices []EditChoice `json:"choices"`
+
+	ResponseMeta
 }
 
 // CreateEdit Creates a new edit for the provided inout, and parameters.
 // POST https://api.openai.com/v1/edits
 func (c *Client) CreateEdit(
 	ctx context.Context,
-	reqBody EditRequestBody) (resBody EditResponseBody, err error) {
-
-	switch reqBody.Model {
-	case TextDavinciEdit001:
-	default:
-		err = errors.New("invalid `model`")
-		return
-	}
-
-	if reqBody.Instruction == "" {
-		err = errors.New("`instruction` not provided")
-		return
-	}
-
+	reqBody EditRequestBody,
+	opts ...RequestOption) (resBody EditResponseBody, err error) {
 	const apiURL = apiURLPrefix + "/v1/edits"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateEdit", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 
 	return
 }
@@ -6,7 +6,7 @@ import (
 )
 
 func TestClient_CreateEdit(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "create_edit")
 	body, err := c.CreateEdit(context.Background(), EditRequestBody{
 		Model:       TextDavinciEdit001,
 		Instruction: "Fix the spelling mistakes",
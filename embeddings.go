@@ -9,6 +9,23 @@ type EmbeddingsRequestBody struct {
 	Model string `json:"model"`
 	Input string `json:"input"`
 	User  string `json:"user,omitempty"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b EmbeddingsRequestBody) MarshalJSON() ([]byte, error) {
+	type plain EmbeddingsRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b EmbeddingsRequestBody) Validate() error {
+	var v validation
+	v.required("model", b.Model)
+	v.required("input", b.Input)
+	return v.err()
 }
 
 type EmbeddingsResponseBody struct {
@@ -19,18 +36,17 @@ type EmbeddingsResponseBody struct {
 		Object    string    `json:"object"`
 		Embedding []float64 `json:"embedding"`
 	} `json:"data"`
+
+	ResponseMeta
 }
 
 // CreateEmbeddings
 // POST https://api.openai.com/v1/embeddings
 func (c *Client) CreateEmbeddings(
 	ctx context.Context,
-	reqBody EmbeddingsRequestBody) (resBody EmbeddingsResponseBody, err error) {
+	reqBody EmbeddingsRequestBody,
+	opts ...RequestOption) (resBody EmbeddingsResponseBody, err error) {
 	const apiURL = apiURLPrefix + "/v1/embeddings"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateEmbeddings", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 	return
 }
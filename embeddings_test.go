@@ -1,6 +1,20 @@
 package openai
 
-import "testing"
+import (
+	"context"
+	"testing"
+)
 
 func TestClient_CreateEmbeddings(t *testing.T) {
+	c := newCassetteClient(t, "create_embeddings")
+	body, err := c.CreateEmbeddings(context.Background(), EmbeddingsRequestBody{
+		Model: TextEmbeddingAda002,
+		Input: "The food was delicious and the waiter...",
+	})
+	if err != nil {
+		t.Fatalf("Create embeddings error: %v", err)
+	}
+	if len(body.Data) != 1 || len(body.Data[0].Embedding) != 1536 {
+		t.Errorf("Create embeddings returned %d embeddings", len(body.Data))
+	}
 }
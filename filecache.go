@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileCache is a CacheStore keeping one file per response in a directory,
+// named after the SHA-256 hash of its key with the extension .cache. Files
+// are written atomically, and the least recently used ones are removed once
+// the directory grows beyond its size limit. Other files of the directory
+// are left alone.
+type FileCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type fileEntry struct {
+	key  string
+	size int64
+}
+
+// NewFileCache returns a FileCache storing responses in dir, which is created
+// if needed, and holding at most maxBytes in total. A zero limit is no limit.
+func NewFileCache(dir string, maxBytes int64) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f := &FileCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type existing struct {
+		entry   *fileEntry
+		modTime time.Time
+	}
+	var files []existing
+	for _, e := range dirEntries {
+		key, ok := strings.CutSuffix(e.Name(), fileCacheExt)
+		if !ok || !isFileKey(key) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, existing{&fileEntry{key: key, size: info.Size()}, info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	for _, file := range files {
+		f.entries[file.entry.key] = f.lru.PushBack(file.entry)
+		f.size += file.entry.size
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.evict()
+	return f, nil
+}
+
+// Get implements CacheStore.
+func (f *FileCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	key = fileKey(key)
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	if len(data) < 8 {
+		return nil, false, nil
+	}
+	if expires := int64(binary.BigEndian.Uint64(data)); expires != 0 && time.Now().UnixNano() > expires {
+		f.mu.Lock()
+		if e, ok := f.entries[key]; ok {
+			f.remove(e)
+		}
+		f.mu.Unlock()
+		return nil, false, nil
+	}
+
+	f.mu.Lock()
+	if e, ok := f.entries[key]; ok {
+		f.lru.MoveToFront(e)
+	}
+	f.mu.Unlock()
+	now := time.Now()
+	_ = os.Chtimes(f.path(key), now, now)
+	return data[8:], true, nil
+}
+
+// Set implements CacheStore.
+func (f *FileCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	key = fileKey(key)
+	data := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(data, uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(data[8:], value)
+
+	tmp, err := os.CreateTemp(f.dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if e, ok := f.entries[key]; ok {
+		f.size -= e.Value.(*fileEntry).size
+		f.lru.Remove(e)
+	}
+	f.entries[key] = f.lru.PushFront(&fileEntry{key: key, size: int64(len(data))})
+	f.size += int64(len(data))
+	f.evict()
+	return nil
+}
+
+// fileCacheExt is the extension of the files of a FileCache.
+const fileCacheExt = ".cache"
+
+// fileKey hashes a key into a file name, whatever characters it holds.
+func fileKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// isFileKey reports whether name was returned by fileKey.
+func isFileKey(name string) bool {
+	if len(name) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range name {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *FileCache) path(key string) string {
+	return filepath.Join(f.dir, key+fileCacheExt)
+}
+
+func (f *FileCache) evict() {
+	for f.maxBytes > 0 && f.size > f.maxBytes && f.lru.Len() > 0 {
+		f.remove(f.lru.Back())
+	}
+}
+
+func (f *FileCache) remove(e *list.Element) {
+	entry := f.lru.Remove(e).(*fileEntry)
+	delete(f.entries, entry.key)
+	f.size -= entry.size
+	_ = os.Remove(f.path(entry.key))
+}
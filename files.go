@@ -13,56 +13,79 @@ type FileObject struct {
 	CreatedAt int    `json:"created_at"`
 	FileName  string `json:"filename"`
 	Purpose   string `json:"purpose"`
+
+	ResponseMeta
 }
 
 type UploadFileRequestBody struct {
 	File    string `json:"file"`
 	Purpose string `json:"purpose"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b UploadFileRequestBody) MarshalJSON() ([]byte, error) {
+	type plain UploadFileRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b UploadFileRequestBody) Validate() error {
+	var v validation
+	v.required("file", b.File)
+	v.required("purpose", b.Purpose)
+	return v.err()
 }
 
 type ListFilesResponseBody struct {
 	Object string       `json:"object"`
 	Data   []FileObject `json:"data"`
+
+	ResponseMeta
 }
 
 type DeleteFileResponseBody struct {
 	ID      string `json:"id"`
 	Object  string `json:"object"`
 	Deleted bool   `json:"deleted"`
+
+	ResponseMeta
 }
 
 type RetrieveFileContentResponseBody struct {
 	Data []byte
+
+	ResponseMeta
 }
 
 // ListFiles Return a list of files that belong to the user's organization.
 // GET https://api.openai.com/v1/files
-func (c *Client) ListFiles(ctx context.Context) (resBody ListFilesResponseBody, err error) {
+func (c *Client) ListFiles(ctx context.Context, opts ...RequestOption) (resBody ListFilesResponseBody, err error) {
 	const apiURL = apiURLPrefix + "/v1/files"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodGet, apiURL, nil); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "ListFiles", http.MethodGet, apiURL, nil, &resBody, opts...)
 
 	return
 }
 
+// PaginateFiles Walks the files that belong to the user's organization page by page.
+// GET https://api.openai.com/v1/files
+func (c *Client) PaginateFiles(params ListParams, opts ...RequestOption) *Paginator[FileObject] {
+	const apiURL = apiURLPrefix + "/v1/files"
+	return paginate(c, "ListFiles", apiURL, params, func(f FileObject) string { return f.ID }, opts)
+}
+
 // UploadFile Upload a file that contains document(s) to be used across various
 // endpoints/features. Currently, the size of all the files uploaded by one organization
 // can be up to 1GB. Please contact us if you need to increase the storage limit.
 // POST https://api.openai.com/v1/files
 func (c *Client) UploadFile(
 	ctx context.Context,
-	reqBody UploadFileRequestBody) (resBody FileObject, err error) {
+	reqBody UploadFileRequestBody,
+	opts ...RequestOption) (resBody FileObject, err error) {
 	const apiURL = apiURLPrefix + "/v1/files"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "UploadFile", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 
 	return
 }
@@ -71,15 +94,11 @@ func (c *Client) UploadFile(
 // DELETE https://api.openai.com/v1/files/{file_id}
 func (c *Client) DeleteFile(
 	ctx context.Context,
-	fileID string) (resBody DeleteFileResponseBody, err error) {
+	fileID string,
+	opts ...RequestOption) (resBody DeleteFileResponseBody, err error) {
 	var apiURL = fmt.Sprintf("%s/v1/files/%s", apiURLPrefix, fileID)
 
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodDelete, apiURL, nil); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "DeleteFile", http.MethodDelete, apiURL, nil, &resBody, opts...)
 
 	return
 }
@@ -88,14 +107,10 @@ func (c *Client) DeleteFile(
 // GET https://api.openai.com/v1/files/{file_id}
 func (c *Client) RetrieveFile(
 	ctx context.Context,
-	fileID string) (resBody FileObject, err error) {
+	fileID string,
+	opts ...RequestOption) (resBody FileObject, err error) {
 	var apiURL = fmt.Sprintf("%s/v1/files/%s", apiURLPrefix, fileID)
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodGet, apiURL, nil); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "RetrieveFile", http.MethodGet, apiURL, nil, &resBody, opts...)
 
 	return
 }
@@ -104,14 +119,10 @@ func (c *Client) RetrieveFile(
 // GET https://api.openai.com/v1/files/{file_id}/content
 func (c *Client) RetrieveFileContent(
 	ctx context.Context,
-	fileID string) (resBody RetrieveFileContentResponseBody, err error) {
+	fileID string,
+	opts ...RequestOption) (resBody RetrieveFileContentResponseBody, err error) {
 	var apiURL = fmt.Sprintf("%s/v1/files/%s/content", apiURLPrefix, fileID)
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodGet, apiURL, nil); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "RetrieveFileContent", http.MethodGet, apiURL, nil, &resBody, opts...)
 
 	return
 }
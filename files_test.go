@@ -6,7 +6,7 @@ import (
 )
 
 func TestClient_ListFiles(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "list_files")
 	body, err := c.ListFiles(context.Background())
 	if err != nil {
 		t.Fatalf("list files error: %v", err)
@@ -1,3 +1,3 @@
 module github.com/im15/openai-api-go
 
-go 1.20
+go 1.21
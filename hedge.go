@@ -0,0 +1,206 @@
+package openai
+
+import (
+	"context"
+	"net/http/httptrace"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HedgeOptions configures HedgeMiddleware.
+type HedgeOptions struct {
+	// Percentile of the recently observed latencies, until response headers,
+	// after which a hedge is sent. 0.95 when zero.
+	Percentile float64
+	// MinDelay and MaxDelay bound the hedge delay. MaxDelay is also used
+	// until enough latencies were observed, 1 second when zero.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// Operations lists the Client methods to hedge. By default chat
+	// completions, completions, edits, embeddings, moderations and model and
+	// file reads are hedged.
+	Operations []string
+}
+
+// minHedgeSamples is the number of latencies observed for an operation
+// before its percentile is used.
+const minHedgeSamples = 20
+
+// maxHedgeSamples is the number of latencies kept per operation.
+const maxHedgeSamples = 200
+
+var defaultHedgedOperations = []string{
+	"CreateChatCompletion", "CreateCompletions", "CreateEdit", "CreateEmbeddings", "CreateModeration",
+	"ListModels", "RetrieveModel", "ListFiles", "RetrieveFile", "RetrieveFileContent",
+}
+
+// HedgeMiddleware returns a Middleware that reduces tail latency by sending
+// a second identical request when the first has not received response
+// headers after a delay derived from the observed latencies. The first
+// successful response is used and the other request is canceled. Streams
+// are never hedged. Responses of hedged calls have Hedged set.
+func HedgeMiddleware(opts HedgeOptions) Middleware {
+	if opts.Percentile <= 0 || opts.Percentile > 1 {
+		opts.Percentile = 0.95
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = time.Second
+	}
+	if len(opts.Operations) == 0 {
+		opts.Operations = defaultHedgedOperations
+	}
+	h := &hedger{
+		opts:       opts,
+		operations: make(map[string]bool, len(opts.Operations)),
+		latencies:  make(map[string][]time.Duration),
+	}
+	for _, op := range opts.Operations {
+		h.operations[op] = true
+	}
+	return func(next Handler) Handler {
+		return func(call *Call) error {
+			if !h.operations[call.Operation] || call.Stream() || call.Result == nil ||
+				(call.Request.Body != nil && call.Request.GetBody == nil) {
+				return next(call)
+			}
+			return h.do(call, next)
+		}
+	}
+}
+
+type hedger struct {
+	opts       HedgeOptions
+	operations map[string]bool
+
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+}
+
+type attempt struct {
+	call    *Call
+	cancel  context.CancelFunc
+	headers chan struct{}
+	err     error
+}
+
+func (h *hedger) do(call *Call, next Handler) error {
+	results := make(chan *attempt, 2)
+	start := func() (*attempt, error) {
+		a, err := h.attempt(call)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			a.err = next(a.call)
+			results <- a
+		}()
+		return a, nil
+	}
+
+	primary, err := start()
+	if err != nil {
+		return err
+	}
+	attempts := []*attempt{primary}
+	defer func() {
+		for _, a := range attempts {
+			a.cancel()
+		}
+	}()
+
+	timer := time.NewTimer(h.delay(call.Operation))
+	defer timer.Stop()
+	var winner *attempt
+	select {
+	case winner = <-results:
+	case <-primary.headers:
+		winner = <-results
+	case <-timer.C:
+		if hedge, err := start(); err == nil {
+			attempts = append(attempts, hedge)
+		}
+		winner = <-results
+		if winner.err != nil && len(attempts) == 2 {
+			// Prefer the other request when the first to finish failed.
+			if other := <-results; other.err == nil {
+				winner = other
+			}
+		}
+	}
+
+	call.Response = winner.call.Response
+	if winner.err != nil {
+		return winner.err
+	}
+	reflect.ValueOf(call.Result).Elem().Set(reflect.ValueOf(winner.call.Result).Elem())
+	if meta := responseMetaOf(call.Result); meta != nil {
+		meta.Hedged = len(attempts) > 1
+	}
+	return nil
+}
+
+// attempt prepares a copy of call with its own request, result and
+// cancellation, recording its latency until response headers.
+func (h *hedger) attempt(call *Call) (*attempt, error) {
+	ctx, cancel := context.WithCancel(call.Context())
+	a := &attempt{cancel: cancel, headers: make(chan struct{})}
+	begin := time.Now()
+	var once sync.Once
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotFirstResponseByte: func() {
+			once.Do(func() {
+				h.observe(call.Operation, time.Since(begin))
+				close(a.headers)
+			})
+		},
+	})
+
+	req := call.Request.Clone(ctx)
+	if call.Request.GetBody != nil {
+		body, err := call.Request.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		req.Body = body
+	}
+	a.call = &Call{
+		Operation: call.Operation,
+		Body:      call.Body,
+		Request:   req,
+		Result:    reflect.New(reflect.TypeOf(call.Result).Elem()).Interface(),
+		options:   call.options,
+	}
+	return a, nil
+}
+
+func (h *hedger) observe(operation string, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := append(h.latencies[operation], latency)
+	if len(samples) > maxHedgeSamples {
+		samples = samples[len(samples)-maxHedgeSamples:]
+	}
+	h.latencies[operation] = samples
+}
+
+// delay returns the hedge delay of an operation.
+func (h *hedger) delay(operation string) time.Duration {
+	h.mu.Lock()
+	samples := append([]time.Duration(nil), h.latencies[operation]...)
+	h.mu.Unlock()
+	if len(samples) < minHedgeSamples {
+		return h.opts.MaxDelay
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	d := samples[int(float64(len(samples)-1)*h.opts.Percentile)]
+	if d < h.opts.MinDelay {
+		d = h.opts.MinDelay
+	}
+	if d > h.opts.MaxDelay {
+		d = h.opts.MaxDelay
+	}
+	return d
+}
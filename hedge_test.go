@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedgeMiddleware(t *testing.T) {
+	var requests atomic.Int32
+	canceled := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices a canceled request once the body was read.
+		_, _ = io.ReadAll(r.Body)
+		if requests.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				close(canceled)
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-ada-002"}`))
+	})
+	c.Use(HedgeMiddleware(HedgeOptions{MaxDelay: 20 * time.Millisecond}))
+
+	start := time.Now()
+	res, err := c.CreateEmbeddings(context.Background(), EmbeddingsRequestBody{
+		Model: TextEmbeddingAda002,
+		Input: "hello",
+	})
+	if err != nil {
+		t.Fatalf("create embeddings error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("hedged call took %s", elapsed)
+	}
+	if !res.Hedged || res.Model != TextEmbeddingAda002 {
+		t.Errorf("response = %+v, want the hedged response", res)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("slow request was not canceled")
+	}
+}
+
+func TestHedgeMiddleware_Fast(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list"}`))
+	})
+	c.Use(HedgeMiddleware(HedgeOptions{MaxDelay: time.Second}))
+
+	res, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list models error: %v", err)
+	}
+	if res.Hedged || requests.Load() != 1 {
+		t.Errorf("fast call was hedged: %d requests", requests.Load())
+	}
+}
+
+func TestHedger_Delay(t *testing.T) {
+	h := &hedger{
+		opts:      HedgeOptions{Percentile: 0.9, MinDelay: 5 * time.Millisecond, MaxDelay: time.Second},
+		latencies: make(map[string][]time.Duration),
+	}
+	if got := h.delay("ListModels"); got != time.Second {
+		t.Errorf("delay without samples = %s, want the max delay", got)
+	}
+	for i := 1; i <= 100; i++ {
+		h.observe("ListModels", time.Duration(i)*time.Millisecond)
+	}
+	if got := h.delay("ListModels"); got != 90*time.Millisecond {
+		t.Errorf("delay = %s, want the 90th percentile", got)
+	}
+}
@@ -2,7 +2,6 @@ package openai
 
 import (
 	"context"
-	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -41,6 +40,30 @@ type ImageRequestBody struct {
 	// A unique identifier representing your end-user,
 	// which can help OpenAI to monitor and detect abuse.
 	User string `json:"user,omitempty"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b ImageRequestBody) MarshalJSON() ([]byte, error) {
+	type plain ImageRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b ImageRequestBody) Validate() error {
+	var v validation
+	v.required("prompt", b.Prompt)
+	v.maxLength("prompt", b.Prompt, 1000)
+	validateImageOptions(&v, b.N, b.Size, b.ResponseFormat)
+	return v.err()
+}
+
+func validateImageOptions(v *validation, n int, size, responseFormat string) {
+	v.intRange("n", n, 1, 10)
+	v.oneOf("size", size, Size256, Size512, Size1024)
+	v.oneOf("response_format", responseFormat, ImageResponseFormat, ImageResponseB64Json)
 }
 
 type ImageEditRequestBody struct {
@@ -72,6 +95,25 @@ type ImageEditRequestBody struct {
 	// [Optional]
 	// A unique identifier representing your end-user, which can help OpenAI to monitor and detect abuse.
 	User string `json:"user,omitempty" multipart:"user"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b ImageEditRequestBody) MarshalJSON() ([]byte, error) {
+	type plain ImageEditRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b ImageEditRequestBody) Validate() error {
+	var v validation
+	v.required("image", b.Image)
+	v.required("prompt", b.Prompt)
+	v.maxLength("prompt", b.Prompt, 1000)
+	validateImageOptions(&v, b.N, b.Size, b.ResponseFormat)
+	return v.err()
 }
 
 func (b ImageEditRequestBody) WriteForm(w *multipart.Writer) (err error) {
@@ -109,21 +151,22 @@ func (b ImageEditRequestBody) WriteForm(w *multipart.Writer) (err error) {
 	}
 
 	if b.Prompt != "" {
-		if err = w.WriteField("prompt", b.Prompt); err != nil {
+		if err = writeFormField(w, b.ExtraFields, "prompt", b.Prompt); err != nil {
 			return
 		}
 	}
 
-	if err = w.WriteField("n", strconv.Itoa(b.N)); err != nil {
+	if err = writeFormField(w, b.ExtraFields, "n", strconv.Itoa(b.N)); err != nil {
 		return
 	}
 
 	if b.Size != "" {
-		if err = w.WriteField("size", b.Size); err != nil {
+		if err = writeFormField(w, b.ExtraFields, "size", b.Size); err != nil {
 			return
 		}
 	}
 
+	err = writeExtraFields(w, b.ExtraFields)
 	return
 }
 
@@ -136,6 +179,23 @@ type ImageVariationRequestBody struct {
 	Size           string `json:"size"`
 	ResponseFormat string `json:"response_format"`
 	User           string `json:"user"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b ImageVariationRequestBody) MarshalJSON() ([]byte, error) {
+	type plain ImageVariationRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b ImageVariationRequestBody) Validate() error {
+	var v validation
+	v.required("image", b.Image)
+	validateImageOptions(&v, b.N, b.Size, b.ResponseFormat)
+	return v.err()
 }
 
 func (b ImageVariationRequestBody) WriteForm(w *multipart.Writer) (err error) {
@@ -157,37 +217,29 @@ func (b ImageVariationRequestBody) WriteForm(w *multipart.Writer) (err error) {
 		return
 	}
 
-	if err = w.WriteField("n", strconv.Itoa(b.N)); err != nil {
+	if err = writeFormField(w, b.ExtraFields, "n", strconv.Itoa(b.N)); err != nil {
 		return
 	}
 
 	if b.Size != "" {
-		if err = w.WriteField("size", b.Size); err != nil {
-			return
-		}
-	}
-	if err = w.WriteField("n", strconv.Itoa(b.N)); err != nil {
-		return
-	}
-
-	if b.Size != "" {
-		if err = w.WriteField("size", b.Size); err != nil {
+		if err = writeFormField(w, b.ExtraFields, "size", b.Size); err != nil {
 			return
 		}
 	}
 
 	if b.ResponseFormat != "" {
-		if err = w.WriteField("response_format", b.ResponseFormat); err != nil {
+		if err = writeFormField(w, b.ExtraFields, "response_format", b.ResponseFormat); err != nil {
 			return
 		}
 	}
 
 	if b.User != "" {
-		if err = w.WriteField("user", b.User); err != nil {
+		if err = writeFormField(w, b.ExtraFields, "user", b.User); err != nil {
 			return
 		}
 	}
 
+	err = writeExtraFields(w, b.ExtraFields)
 	return
 }
 
@@ -198,19 +250,18 @@ type ImageData struct {
 type ImageResponseBody struct {
 	Created int         `json:"created"`
 	Data    []ImageData `json:"data"`
+
+	ResponseMeta
 }
 
 // CreateImage Creates an image given a prompt.
 // POST https://api.openai.com/v1/images/generations
 func (c *Client) CreateImage(
 	ctx context.Context,
-	reqBody ImageRequestBody) (resBody ImageResponseBody, err error) {
+	reqBody ImageRequestBody,
+	opts ...RequestOption) (resBody ImageResponseBody, err error) {
 	const apiURL = "https://api.openai.com/v1/images/generations"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateImage", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 	return
 }
 
@@ -218,28 +269,11 @@ func (c *Client) CreateImage(
 // POST https://api.openai.com/v1/images/edits
 func (c *Client) CreateImageEdit(
 	ctx context.Context,
-	reqBody ImageEditRequestBody) (resBody ImageResponseBody, err error) {
+	reqBody ImageEditRequestBody,
+	opts ...RequestOption) (resBody ImageResponseBody, err error) {
 	const apiURL = "https://api.openai.com/v1/images/edits"
 
-	switch reqBody.Size {
-	case Size256, Size512, Size1024, "":
-	default:
-		err = errors.New("invalid `size`")
-		return
-	}
-
-	switch reqBody.ResponseFormat {
-	case ImageResponseFormat, ImageResponseB64Json, "":
-	default:
-		err = errors.New("invalid `response_format`")
-		return
-	}
-
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateImageEdit", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 	return
 }
 
@@ -247,12 +281,9 @@ func (c *Client) CreateImageEdit(
 // POST https://api.openai.com/v1/images/variations
 func (c *Client) CreateImageVariation(
 	ctx context.Context,
-	reqBody ImageVariationRequestBody) (resBody ImageResponseBody, err error) {
+	reqBody ImageVariationRequestBody,
+	opts ...RequestOption) (resBody ImageResponseBody, err error) {
 	const apiURL = "https://api.openai.com/v1/images/variations"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateImageVariation", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 	return
 }
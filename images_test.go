@@ -6,7 +6,7 @@ import (
 )
 
 func TestClient_CreateImage(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "create_image")
 	body, err := c.CreateImage(context.Background(), ImageRequestBody{
 		Prompt: "A cute baby sea otter",
 		N:      3,
@@ -23,10 +23,10 @@ func TestClient_CreateImage(t *testing.T) {
 }
 
 func TestClient_CreateImageEdit(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "create_image_edit")
 	body, err := c.CreateImageEdit(context.Background(), ImageEditRequestBody{
-		Image:  "",
-		Prompt: "",
+		Image:  "testdata/otter.png",
+		Prompt: "A cute baby sea otter wearing a beret",
 		N:      3,
 		//Size:           "",
 		//ResponseFormat: "",
@@ -44,9 +44,9 @@ func TestClient_CreateImageEdit(t *testing.T) {
 }
 
 func TestClient_CreateImageVariation(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "create_image_variation")
 	body, err := c.CreateImageVariation(context.Background(), ImageVariationRequestBody{
-		Image: "",
+		Image: "testdata/otter.png",
 		N:     3,
 	})
 
@@ -0,0 +1,75 @@
+package openai
+
+import "context"
+
+// ChatAPI creates chat completions.
+type ChatAPI interface {
+	CreateChatCompletion(ctx context.Context, body ChatRequestBody, opts ...RequestOption) (*ChatResponseBody, error)
+}
+
+// CompletionsAPI creates text completions.
+type CompletionsAPI interface {
+	CreateCompletions(ctx context.Context, reqBody CompletionRequestBody, opts ...RequestOption) (CompletionResponseBody, error)
+}
+
+// EditsAPI creates edits.
+type EditsAPI interface {
+	CreateEdit(ctx context.Context, reqBody EditRequestBody, opts ...RequestOption) (EditResponseBody, error)
+}
+
+// EmbeddingsAPI creates embeddings.
+type EmbeddingsAPI interface {
+	CreateEmbeddings(ctx context.Context, reqBody EmbeddingsRequestBody, opts ...RequestOption) (EmbeddingsResponseBody, error)
+}
+
+// ImagesAPI creates, edits and varies images.
+type ImagesAPI interface {
+	CreateImage(ctx context.Context, reqBody ImageRequestBody, opts ...RequestOption) (ImageResponseBody, error)
+	CreateImageEdit(ctx context.Context, reqBody ImageEditRequestBody, opts ...RequestOption) (ImageResponseBody, error)
+	CreateImageVariation(ctx context.Context, reqBody ImageVariationRequestBody, opts ...RequestOption) (ImageResponseBody, error)
+}
+
+// AudioAPI transcribes and translates audio.
+type AudioAPI interface {
+	CreateTranscription(ctx context.Context, reqBody AudioRequestBody, opts ...RequestOption) (AudioResponseBody, error)
+	CreateTranslation(ctx context.Context, reqBody AudioRequestBody, opts ...RequestOption) (AudioResponseBody, error)
+}
+
+// FilesAPI manages uploaded files.
+type FilesAPI interface {
+	ListFiles(ctx context.Context, opts ...RequestOption) (ListFilesResponseBody, error)
+	PaginateFiles(params ListParams, opts ...RequestOption) *Paginator[FileObject]
+	UploadFile(ctx context.Context, reqBody UploadFileRequestBody, opts ...RequestOption) (FileObject, error)
+	DeleteFile(ctx context.Context, fileID string, opts ...RequestOption) (DeleteFileResponseBody, error)
+	RetrieveFile(ctx context.Context, fileID string, opts ...RequestOption) (FileObject, error)
+	RetrieveFileContent(ctx context.Context, fileID string, opts ...RequestOption) (RetrieveFileContentResponseBody, error)
+}
+
+// ModelsAPI lists and retrieves models.
+type ModelsAPI interface {
+	ListModels(ctx context.Context, opts ...RequestOption) (*ModelsResponseBody, error)
+	PaginateModels(params ListParams, opts ...RequestOption) *Paginator[ModelObject]
+	RetrieveModel(ctx context.Context, model string, opts ...RequestOption) (ModelObject, error)
+}
+
+// ModerationsAPI classifies content.
+type ModerationsAPI interface {
+	CreateModeration(ctx context.Context, reqBody ModerationRequestBody, opts ...RequestOption) (ModerationResponseBody, error)
+}
+
+// API is the whole API as implemented by Client. Code that depends on it
+// rather than on *Client can be tested with a fake, such as openaitest.Fake,
+// or have its calls decorated.
+type API interface {
+	ChatAPI
+	CompletionsAPI
+	EditsAPI
+	EmbeddingsAPI
+	ImagesAPI
+	AudioAPI
+	FilesAPI
+	ModelsAPI
+	ModerationsAPI
+}
+
+var _ API = (*Client)(nil)
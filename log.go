@@ -0,0 +1,190 @@
+package openai
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogContent selects user content that may appear in logs. Content that is
+// not selected is replaced by "[REDACTED]".
+type LogContent uint8
+
+const (
+	// LogMessages reveals chat messages, prompts and inputs.
+	LogMessages LogContent = 1 << iota
+	// LogFileNames reveals names of uploaded files, images and audio.
+	LogFileNames
+	// LogImagePrompts reveals prompts of image generations and edits.
+	LogImagePrompts
+)
+
+const redacted = "[REDACTED]"
+
+// LogOptions configures what the Client logs.
+type LogOptions struct {
+	// Level is the level of the summary logged for every successful call,
+	// slog.LevelDebug when nil.
+	Level slog.Leveler
+	// ErrorLevel is the level used for failed calls, slog.LevelError when nil.
+	ErrorLevel slog.Leveler
+	// Content selects the user content included in request summaries. The
+	// zero value redacts all of it.
+	Content LogContent
+}
+
+func (o *LogOptions) level() slog.Level {
+	if o == nil || o.Level == nil {
+		return slog.LevelDebug
+	}
+	return o.Level.Level()
+}
+
+func (o *LogOptions) errorLevel() slog.Level {
+	if o == nil || o.ErrorLevel == nil {
+		return slog.LevelError
+	}
+	return o.ErrorLevel.Level()
+}
+
+func (o *LogOptions) reveal(content LogContent) bool {
+	return o != nil && o.Content&content != 0
+}
+
+func (o *LogOptions) value(content LogContent, s string) string {
+	if s == "" || o.reveal(content) {
+		return s
+	}
+	return redacted
+}
+
+// logMiddleware logs a summary of every call. The Authorization header is
+// never part of it.
+func (c *Client) logMiddleware(next Handler) Handler {
+	return func(call *Call) error {
+		start := time.Now()
+		err := next(call)
+
+		ctx := call.Context()
+		level := c.LogOptions.level()
+		if err != nil {
+			level = c.LogOptions.errorLevel()
+		}
+		if !c.Logger.Enabled(ctx, level) {
+			return err
+		}
+
+		attrs := []slog.Attr{
+			slog.String("operation", call.Operation),
+			slog.String("method", call.Request.Method),
+			slog.String("path", call.Request.URL.Path),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if model := call.Model(); model != "" {
+			attrs = append(attrs, slog.String("model", model))
+		}
+		if call.Response != nil {
+			attrs = append(attrs, slog.Int("status", call.Response.StatusCode))
+		}
+		if call.Stream() {
+			attrs = append(attrs, slog.Bool("stream", true))
+		}
+		if request := c.LogOptions.requestAttrs(call.Body); len(request) > 0 {
+			attrs = append(attrs, slog.Any("request", slog.GroupValue(request...)))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+			c.Logger.LogAttrs(ctx, level, "openai: request failed", attrs...)
+			return err
+		}
+		if usage := call.Usage(); usage != nil && usage.TotalTokens > 0 {
+			attrs = append(attrs, slog.Group("usage",
+				slog.Int("prompt_tokens", usage.PromptTokens),
+				slog.Int("completion_tokens", usage.CompletionTokens),
+				slog.Int("total_tokens", usage.TotalTokens)))
+		}
+		c.Logger.LogAttrs(ctx, level, "openai: request", attrs...)
+		return err
+	}
+}
+
+// requestAttrs describes the user content of a request body, redacted as
+// configured.
+func (o *LogOptions) requestAttrs(body any) []slog.Attr {
+	switch b := body.(type) {
+	case ChatRequestBody:
+		messages := make([]slog.Attr, 0, len(b.Messages))
+		for i, m := range b.Messages {
+			if m == nil {
+				continue
+			}
+			messages = append(messages, slog.Group(strconv.Itoa(i), o.messageAttrs(m)...))
+		}
+		return []slog.Attr{{Key: "messages", Value: slog.GroupValue(messages...)}}
+	case CompletionRequestBody:
+		return []slog.Attr{slog.String("prompt", o.value(LogMessages, b.Prompt))}
+	case EditRequestBody:
+		return []slog.Attr{
+			slog.String("input", o.value(LogMessages, b.Input)),
+			slog.String("instruction", o.value(LogMessages, b.Instruction)),
+		}
+	case EmbeddingsRequestBody:
+		return []slog.Attr{slog.String("input", o.value(LogMessages, b.Input))}
+	case ModerationRequestBody:
+		return []slog.Attr{slog.String("input", o.value(LogMessages, b.Input))}
+	case ImageRequestBody:
+		return []slog.Attr{slog.String("prompt", o.value(LogImagePrompts, b.Prompt))}
+	case ImageEditRequestBody:
+		return []slog.Attr{
+			slog.String("image", o.value(LogFileNames, b.Image)),
+			slog.String("mask", o.value(LogFileNames, b.Mask)),
+			slog.String("prompt", o.value(LogImagePrompts, b.Prompt)),
+		}
+	case ImageVariationRequestBody:
+		return []slog.Attr{slog.String("image", o.value(LogFileNames, b.Image))}
+	case AudioRequestBody:
+		return []slog.Attr{
+			slog.String("file", o.value(LogFileNames, b.File)),
+			slog.String("prompt", o.value(LogMessages, b.Prompt)),
+		}
+	case UploadFileRequestBody:
+		return []slog.Attr{slog.String("file", o.value(LogFileNames, b.File))}
+	}
+	return nil
+}
+
+// messageAttrs describes a chat message: its role, its content or the text
+// parts of its MultiContent, and the number of images it holds.
+func (o *LogOptions) messageAttrs(m *ChatMessage) []any {
+	content := m.Content
+	var text []string
+	images := 0
+	for _, p := range m.MultiContent {
+		switch p.Type {
+		case ChatMessagePartTypeText:
+			text = append(text, p.Text)
+		case ChatMessagePartTypeImageURL:
+			images++
+		}
+	}
+	if len(m.MultiContent) > 0 {
+		content = strings.Join(text, "\n")
+	}
+	attrs := []any{
+		slog.String("role", m.Role),
+		slog.String("content", o.value(LogMessages, content)),
+	}
+	if images > 0 {
+		attrs = append(attrs, slog.Int("images", images))
+	}
+	return attrs
+}
+
+func (c *Client) logStreamError(ctx context.Context, err error) {
+	if c.Logger != nil {
+		c.Logger.LogAttrs(ctx, c.LogOptions.errorLevel(), "openai: invalid stream chunk",
+			slog.Any("error", err))
+	}
+}
@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_Logger(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`))
+	})
+	var buf bytes.Buffer
+	c.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := c.CreateChatCompletion(context.Background(), ChatRequestBody{
+		Model:    GPT35Turbo,
+		Messages: []*ChatMessage{{Role: RoleUser, Content: "my secret question"}},
+	})
+	if err != nil {
+		t.Fatalf("create chat completion error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"level=DEBUG", "operation=CreateChatCompletion", "model=gpt-3.5-turbo",
+		"status=200", "request.messages.0.content=[REDACTED]", "usage.total_tokens=7"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q does not contain %q", out, want)
+		}
+	}
+	for _, secret := range []string{"my secret question", "test-token"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log %q contains %q", out, secret)
+		}
+	}
+
+	buf.Reset()
+	c.LogOptions = &LogOptions{Level: slog.LevelInfo, Content: LogMessages}
+	_, _ = c.CreateChatCompletion(context.Background(), ChatRequestBody{
+		Model:    GPT35Turbo,
+		Messages: []*ChatMessage{{Role: RoleUser, Content: "hello"}},
+	})
+	if out = buf.String(); !strings.Contains(out, "level=INFO") || !strings.Contains(out, "content=hello") {
+		t.Errorf("log %q does not reveal the message at info level", out)
+	}
+}
+
+func TestClient_LoggerError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`))
+	})
+	var buf bytes.Buffer
+	c.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	_, err := c.CreateImage(context.Background(), ImageRequestBody{Prompt: "a private sketch"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("error = %v, want *Error with status 401", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"level=ERROR", `error="Incorrect API key provided"`, "status=401",
+		"request.prompt=[REDACTED]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q does not contain %q", out, want)
+		}
+	}
+}
+
+func TestLogOptions_requestAttrs(t *testing.T) {
+	o := &LogOptions{Content: LogMessages}
+	attrs := o.requestAttrs(ChatRequestBody{Messages: []*ChatMessage{
+		nil,
+		{Role: RoleUser, MultiContent: []ChatMessagePart{
+			{Type: ChatMessagePartTypeText, Text: "what is"},
+			{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: "data:image/png;base64,AAAA"}},
+			{Type: ChatMessagePartTypeText, Text: "in this image?"},
+		}},
+	}})
+	got := slog.GroupValue(attrs...).String()
+	if want := "[messages=[1=[role=user content=what is\nin this image? images=1]]]"; got != want {
+		t.Errorf("requestAttrs() = %s, want %s", got, want)
+	}
+}
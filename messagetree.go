@@ -0,0 +1,302 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ErrMessageNotFound is returned for an ID that is not in a MessageTree.
+var ErrMessageNotFound = errors.New("message not found")
+
+// ErrNilMessage is returned when a MessageTree is given a nil message to
+// fork or edit with.
+var ErrNilMessage = errors.New("nil message")
+
+// MessageNode is a message of a MessageTree.
+type MessageNode struct {
+	ID string `json:"id"`
+	// ParentID is the ID of the message it follows, "" for the first
+	// messages.
+	ParentID string       `json:"parent_id,omitempty"`
+	Message  *ChatMessage `json:"message"`
+	// Children are the IDs of the messages following it, in the order they
+	// were added: a reply, and its edits or regenerations.
+	Children []string `json:"children,omitempty"`
+	// Active is the index in Children of the branch being followed.
+	Active int `json:"active,omitempty"`
+}
+
+// MessageTree is the history of a chat whose messages can be edited or
+// regenerated, each edit starting a branch next to the original message.
+// One branch is current: from the first message, it follows the active child
+// of every message down to a leaf. A MessageTree is safe for concurrent use.
+type MessageTree struct {
+	mu sync.RWMutex
+	// nodes holds the messages by ID, and a root with ID "" whose children
+	// are the first messages.
+	nodes   map[string]*MessageNode
+	current string
+	last    int
+}
+
+// NewMessageTree returns an empty MessageTree.
+func NewMessageTree() *MessageTree {
+	return &MessageTree{nodes: map[string]*MessageNode{"": {}}}
+}
+
+// Append adds messages at the end of the current branch, skipping nil ones,
+// and returns the ID of the last one.
+func (t *MessageTree) Append(messages ...*ChatMessage) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, m := range messages {
+		if m != nil {
+			t.current = t.add(t.current, m)
+		}
+	}
+	return t.current
+}
+
+// Fork adds a message after the one with ID parentID, "" to start a new
+// conversation, and makes the new branch current. It returns the ID of the
+// message.
+func (t *MessageTree) Fork(parentID string, message *ChatMessage) (string, error) {
+	if message == nil {
+		return "", ErrNilMessage
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.nodes[parentID]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrMessageNotFound, parentID)
+	}
+	t.current = t.add(parentID, message)
+	return t.current, nil
+}
+
+// Edit adds message as an alternative to the message with an ID, following
+// the same parent, and makes it current. It returns the ID of the edit.
+func (t *MessageTree) Edit(id string, message *ChatMessage) (string, error) {
+	if message == nil {
+		return "", ErrNilMessage
+	}
+	t.mu.RLock()
+	node, ok := t.nodes[id]
+	t.mu.RUnlock()
+	if !ok || id == "" {
+		return "", fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	return t.Fork(node.ParentID, message)
+}
+
+// Regenerate asks api for an alternative to the message with an ID, from the
+// messages leading to it, and makes it current. The request is body with
+// those messages; it must not be streamed. It returns the ID of the new
+// reply.
+func (t *MessageTree) Regenerate(ctx context.Context,
+	api ChatAPI,
+	id string,
+	body ChatRequestBody,
+	opts ...RequestOption) (string, error) {
+	t.mu.RLock()
+	node, ok := t.nodes[id]
+	var parentID string
+	if ok && id != "" {
+		parentID = node.ParentID
+		body.Messages = t.linearize(parentID)
+	}
+	t.mu.RUnlock()
+	if !ok || id == "" {
+		return "", fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	res, err := api.CreateChatCompletion(ctx, body, opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(res.Choices) == 0 || res.Choices[0].Message == nil {
+		return "", ErrNoChoice
+	}
+	return t.Fork(parentID, res.Choices[0].Message)
+}
+
+// Switch makes current the branch through the message with an ID, following
+// the active children below it.
+func (t *MessageTree) Switch(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	node, ok := t.nodes[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	t.activate(node)
+	for len(node.Children) > 0 {
+		node = t.nodes[node.Children[node.Active]]
+	}
+	t.current = node.ID
+	return nil
+}
+
+// Current returns the ID of the last message of the current branch, "" when
+// the tree is empty.
+func (t *MessageTree) Current() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.current
+}
+
+// Node returns a copy of the message with an ID, reporting false when there
+// is none.
+func (t *MessageTree) Node(id string) (MessageNode, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	node, ok := t.nodes[id]
+	if !ok || id == "" {
+		return MessageNode{}, false
+	}
+	copied := *node
+	copied.Message = copyMessage(node.Message)
+	copied.Children = append([]string(nil), node.Children...)
+	return copied, true
+}
+
+// Linearize returns the messages from the first one to the one with an ID,
+// as ChatRequestBody.Messages.
+func (t *MessageTree) Linearize(id string) ([]*ChatMessage, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if _, ok := t.nodes[id]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, id)
+	}
+	return t.linearize(id), nil
+}
+
+// Messages returns the messages of the current branch.
+func (t *MessageTree) Messages() []*ChatMessage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.linearize(t.current)
+}
+
+func (t *MessageTree) add(parentID string, message *ChatMessage) string {
+	t.last++
+	id := strconv.Itoa(t.last)
+	node := &MessageNode{ID: id, ParentID: parentID, Message: copyMessage(message)}
+	t.nodes[id] = node
+	parent := t.nodes[parentID]
+	parent.Children = append(parent.Children, id)
+	t.activate(node)
+	return id
+}
+
+// activate makes the branch through a node the active one of each of its
+// ancestors.
+func (t *MessageTree) activate(node *MessageNode) {
+	for child := node; child.ID != ""; {
+		parent := t.nodes[child.ParentID]
+		for i, c := range parent.Children {
+			if c == child.ID {
+				parent.Active = i
+			}
+		}
+		child = parent
+	}
+}
+
+func (t *MessageTree) linearize(id string) []*ChatMessage {
+	var path []*ChatMessage
+	for node := t.nodes[id]; node.ID != ""; node = t.nodes[node.ParentID] {
+		path = append(path, node.Message)
+	}
+	messages := make([]*ChatMessage, len(path))
+	for i, m := range path {
+		messages[len(path)-1-i] = copyMessage(m)
+	}
+	return messages
+}
+
+// messageTreeState is the JSON encoding of a MessageTree.
+type messageTreeState struct {
+	// Roots are the IDs of the first messages.
+	Roots   []string      `json:"roots"`
+	Active  int           `json:"active,omitempty"`
+	Current string        `json:"current,omitempty"`
+	Nodes   []MessageNode `json:"nodes"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding the messages in the order
+// they were added.
+func (t *MessageTree) MarshalJSON() ([]byte, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	root := t.nodes[""]
+	s := messageTreeState{
+		Roots:   append([]string{}, root.Children...),
+		Active:  root.Active,
+		Current: t.current,
+		Nodes:   make([]MessageNode, 0, len(t.nodes)-1),
+	}
+	for id, node := range t.nodes {
+		if id != "" {
+			s.Nodes = append(s.Nodes, *node)
+		}
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool {
+		a, _ := strconv.Atoi(s.Nodes[i].ID)
+		b, _ := strconv.Atoi(s.Nodes[j].ID)
+		return a < b
+	})
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, checking that the nodes form a
+// tree.
+func (t *MessageTree) UnmarshalJSON(data []byte) error {
+	var s messageTreeState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	nodes := map[string]*MessageNode{"": {Children: s.Roots, Active: s.Active}}
+	last := 0
+	for i := range s.Nodes {
+		node := &s.Nodes[i]
+		if _, ok := nodes[node.ID]; ok || node.Message == nil {
+			return fmt.Errorf("message tree: invalid node %q", node.ID)
+		}
+		nodes[node.ID] = node
+		if n, err := strconv.Atoi(node.ID); err == nil {
+			last = max(last, n)
+		}
+	}
+	// Every node but the root is reached from it once.
+	seen := make(map[string]bool, len(nodes))
+	for stack := []string{""}; len(stack) > 0; {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		node := nodes[id]
+		if len(node.Children) > 0 && (node.Active < 0 || node.Active >= len(node.Children)) {
+			return fmt.Errorf("message tree: invalid active child of %q", id)
+		}
+		for _, child := range node.Children {
+			c, ok := nodes[child]
+			if !ok || child == "" || c.ParentID != id || seen[child] {
+				return fmt.Errorf("message tree: invalid child %q of %q", child, id)
+			}
+			seen[child] = true
+			stack = append(stack, child)
+		}
+	}
+	if len(seen) != len(nodes)-1 {
+		return errors.New("message tree: nodes unreachable from the first messages")
+	}
+	if _, ok := nodes[s.Current]; !ok {
+		return fmt.Errorf("%w: %s", ErrMessageNotFound, s.Current)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nodes, t.current, t.last = nodes, s.Current, last
+	return nil
+}
@@ -0,0 +1,153 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMessageTree(t *testing.T) {
+	tree := NewMessageTree()
+	tree.Append(&ChatMessage{Role: RoleSystem, Content: "sys"})
+	u1 := tree.Append(&ChatMessage{Role: RoleUser, Content: "u1"})
+	a1 := tree.Append(&ChatMessage{Role: RoleAssistant, Content: "a1"})
+	tree.Append(&ChatMessage{Role: RoleUser, Content: "u2"}, &ChatMessage{Role: RoleAssistant, Content: "a2"})
+
+	// Editing u1 starts a branch without its replies.
+	edited, err := tree.Edit(u1, &ChatMessage{Role: RoleUser, Content: "u1'"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(tree.Messages()); got != "sys,u1'" {
+		t.Errorf("Messages() after Edit = %s", got)
+	}
+	// Regenerating a reply sends the messages before it.
+	var sent []*ChatMessage
+	api := chatFunc(func(ctx context.Context, body ChatRequestBody) (*ChatResponseBody, error) {
+		sent = body.Messages
+		return echoChat(ctx, body)
+	})
+	reply, err := tree.Regenerate(context.Background(), api, tree.Append(&ChatMessage{Role: RoleAssistant, Content: "a1'"}), ChatRequestBody{Model: GPT4o})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(sent); got != "sys,u1'" {
+		t.Errorf("Regenerate() sent %s", got)
+	}
+	if got := contents(tree.Messages()); got != "sys,u1',2: u1'" || tree.Current() != reply {
+		t.Errorf("Messages() after Regenerate = %s", got)
+	}
+	if node, _ := tree.Node(edited); len(node.Children) != 2 || node.Active != 1 {
+		t.Errorf("Node(%s) = %+v", edited, node)
+	}
+
+	// Switching back to the original branch follows it to its end.
+	if err = tree.Switch(a1); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(tree.Messages()); got != "sys,u1,a1,u2,a2" {
+		t.Errorf("Messages() after Switch = %s", got)
+	}
+	if messages, err := tree.Linearize(a1); err != nil || contents(messages) != "sys,u1,a1" {
+		t.Errorf("Linearize(a1) = %s, %v", contents(messages), err)
+	}
+	if err = tree.Switch("404"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Switch(404) = %v, want ErrMessageNotFound", err)
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewMessageTree()
+	if err = json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(restored.Messages()); got != "sys,u1,a1,u2,a2" {
+		t.Errorf("restored Messages() = %s", got)
+	}
+	if err = restored.Switch(edited); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(restored.Messages()); got != "sys,u1',2: u1'" {
+		t.Errorf("restored Messages() after Switch = %s", got)
+	}
+	if id := restored.Append(&ChatMessage{Role: RoleUser, Content: "u3"}); id != "9" {
+		t.Errorf("Append() after restore = %s, want 9", id)
+	}
+
+	for name, data := range map[string]string{
+		"cycle":          `{"roots":["1"],"nodes":[{"id":"1","message":{"role":"user","content":""}},{"id":"2","parent_id":"3","message":{"role":"user","content":""},"children":["3"]},{"id":"3","parent_id":"2","message":{"role":"user","content":""},"children":["2"]}]}`,
+		"wrong parent":   `{"roots":["1"],"nodes":[{"id":"1","parent_id":"2","message":{"role":"user","content":""}}]}`,
+		"invalid active": `{"roots":["1"],"active":1,"nodes":[{"id":"1","message":{"role":"user","content":""}}]}`,
+	} {
+		if err = json.Unmarshal([]byte(data), NewMessageTree()); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", name)
+		}
+	}
+}
+
+func TestMessageTree_forkActivatesAncestors(t *testing.T) {
+	tree := NewMessageTree()
+	u1 := tree.Append(&ChatMessage{Role: RoleUser, Content: "u1"})
+	a1 := tree.Append(&ChatMessage{Role: RoleAssistant, Content: "a1"})
+	if _, err := tree.Edit(u1, &ChatMessage{Role: RoleUser, Content: "u1'"}); err != nil {
+		t.Fatal(err)
+	}
+	// Forking from the original branch makes it active again up to the root.
+	if _, err := tree.Fork(a1, &ChatMessage{Role: RoleUser, Content: "u2"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(tree.Messages()); got != "u1,a1,u2" {
+		t.Errorf("Messages() after Fork = %s", got)
+	}
+	if node, _ := tree.Node(u1); node.Children[node.Active] != a1 {
+		t.Errorf("Node(%s) = %+v, want %s active", u1, node, a1)
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s messageTreeState
+	if err = json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Roots[s.Active] != u1 {
+		t.Errorf("active root = %s, want %s", s.Roots[s.Active], u1)
+	}
+}
+
+func TestMessageTree_copies(t *testing.T) {
+	tree := NewMessageTree()
+	if id := tree.Append(nil); id != "" {
+		t.Errorf("Append(nil) = %q, want no message", id)
+	}
+	m := &ChatMessage{
+		Role:         RoleAssistant,
+		MultiContent: []ChatMessagePart{{Type: ChatMessagePartTypeText, Text: "look"}},
+		ToolCalls:    []ToolCall{{ID: "c1", Type: ToolTypeFunction, Function: FunctionCall{Name: "f"}}},
+	}
+	id := tree.Append(nil, m, nil)
+	if id != "1" {
+		t.Fatalf("Append() = %q, want 1", id)
+	}
+	if _, err := tree.Fork(id, nil); !errors.Is(err, ErrNilMessage) {
+		t.Errorf("Fork(nil) = %v, want ErrNilMessage", err)
+	}
+	if _, err := tree.Edit(id, nil); !errors.Is(err, ErrNilMessage) {
+		t.Errorf("Edit(nil) = %v, want ErrNilMessage", err)
+	}
+
+	// Neither the caller's message nor the returned ones share parts with
+	// the tree.
+	m.MultiContent[0].Text = "changed"
+	m.ToolCalls[0].Function.Name = "changed"
+	tree.Messages()[0].ToolCalls[0].Function.Name = "changed"
+	node, _ := tree.Node(id)
+	node.Message.MultiContent[0].Text = "changed"
+	got := tree.Messages()[0]
+	if got.MultiContent[0].Text != "look" || got.ToolCalls[0].Function.Name != "f" {
+		t.Errorf("message = %+v, changed through a copy", got)
+	}
+}
@@ -0,0 +1,172 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+)
+
+// Call describes a single API call while it passes through the middleware
+// chain. Middlewares may inspect or modify it before calling the next handler;
+// once next returns, Response and Result are populated.
+type Call struct {
+	// Operation is the name of the Client method, e.g. "CreateChatCompletion".
+	Operation string
+	// Body is the typed request body passed to the method, nil when the
+	// endpoint takes none.
+	Body any
+	// Request is the outgoing HTTP request.
+	Request *http.Request
+	// Response is the HTTP response. It is nil until the request was sent, or
+	// when no response was received. Its body has already been consumed.
+	Response *http.Response
+	// Result is a pointer to the value the response body is decoded into.
+	Result any
+
+	options *requestOptions
+}
+
+// Context returns the context of the outgoing request.
+func (c *Call) Context() context.Context {
+	return c.Request.Context()
+}
+
+// Handler executes a Call and returns its error.
+type Handler func(call *Call) error
+
+// Middleware wraps a Handler. A middleware may act before and after calling
+// next, or return without calling it at all to short-circuit the request.
+type Middleware func(next Handler) Handler
+
+// Use appends middlewares to the client. They run in the order given, the
+// first one being the outermost. Use is not safe for concurrent use with
+// in-flight requests and should be called while setting up the client.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+func (c *Client) handler() Handler {
+	h := c.retryMiddleware(c.getRequest)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+	if c.Logger != nil {
+		h = c.logMiddleware(h)
+	}
+	return h
+}
+
+// do builds the request for an operation and runs it through the middleware
+// chain, decoding the response into v.
+func (c *Client) do(ctx context.Context,
+	operation string,
+	method string,
+	url string,
+	body any,
+	v any,
+	opts ...RequestOption) error {
+	options := newRequestOptions(opts)
+	if !c.SkipValidation && !options.skipValidation {
+		if b, ok := body.(Validator); ok {
+			if err := b.Validate(); err != nil {
+				return err
+			}
+		}
+		if err := c.checkModel(ctx, operation, modelOf(body)); err != nil {
+			return err
+		}
+	}
+	cancel := context.CancelFunc(func() {})
+	if options.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+	}
+	req, err := c.newRequest(ctx, method, url, body, options)
+	if err != nil {
+		cancel()
+		return err
+	}
+	call := &Call{
+		Operation: operation,
+		Body:      body,
+		Request:   req,
+		Result:    v,
+		options:   options,
+	}
+	// A stream outlives the call, its timeout is canceled once it is read.
+	if err = c.handler()(call); err != nil || options.timeout == 0 || !call.InterceptStream(nil, cancel) {
+		cancel()
+	}
+	return err
+}
+
+// Model returns the model named in the request body, or "" when the body
+// does not carry one.
+func (c *Call) Model() string {
+	return modelOf(c.Body)
+}
+
+func modelOf(body any) string {
+	switch b := body.(type) {
+	case ChatRequestBody:
+		return b.Model
+	case CompletionRequestBody:
+		return b.Model
+	case EditRequestBody:
+		return b.Model
+	case EmbeddingsRequestBody:
+		return b.Model
+	case AudioRequestBody:
+		return b.Model
+	case ModerationRequestBody:
+		return b.Model
+	}
+	return ""
+}
+
+// Usage returns the token usage reported in the decoded result, or nil when
+// the endpoint does not report any.
+func (c *Call) Usage() *TokensUsage {
+	switch r := c.Result.(type) {
+	case *ChatResponseBody:
+		return &r.Usage
+	case *CompletionResponseBody:
+		return &r.Usage
+	case *EditResponseBody:
+		return &r.Usage
+	case *EmbeddingsResponseBody:
+		return &r.Usage
+	}
+	return nil
+}
+
+// Stream reports whether the call is a streaming chat completion.
+func (c *Call) Stream() bool {
+	b, ok := c.Body.(ChatRequestBody)
+	return ok && b.Stream
+}
+
+// InterceptStream makes every chunk of a streaming chat completion pass
+// through fn before it reaches the caller, and calls done once the stream is
+// closed. Either function may be nil. It must be called after next returned
+// without error, and reports false when the call did not produce a stream.
+func (c *Call) InterceptStream(fn func(chunk *ChatStreamChunk), done func()) bool {
+	res, ok := c.Result.(*ChatResponseBody)
+	if !ok || res.StreamChan == nil {
+		return false
+	}
+	in := res.StreamChan
+	out := make(chan *ChatStreamChunk, cap(in))
+	res.StreamChan = out
+	go func() {
+		defer close(out)
+		for chunk := range in {
+			if fn != nil {
+				fn(chunk)
+			}
+			out <- chunk
+		}
+		if done != nil {
+			done()
+		}
+	}()
+	return true
+}
@@ -0,0 +1,86 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClient_Use(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Trace"); got != "abc" {
+			t.Errorf("X-Trace = %q, want %q", got, "abc")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"embedding","model":"text-embedding-ada-002"}`))
+	})
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(call *Call) error {
+				order = append(order, name+":before")
+				err := next(call)
+				order = append(order, name+":after")
+				return err
+			}
+		}
+	}
+	c.Use(trace("outer"), func(next Handler) Handler {
+		return func(call *Call) error {
+			if call.Operation != "CreateEmbeddings" {
+				t.Errorf("Operation = %q", call.Operation)
+			}
+			if b, ok := call.Body.(EmbeddingsRequestBody); !ok || b.Input != "hello" {
+				t.Errorf("Body = %#v", call.Body)
+			}
+			call.Request.Header.Set("X-Trace", "abc")
+			err := next(call)
+			if call.Response == nil || call.Response.StatusCode != http.StatusOK {
+				t.Errorf("Response = %v", call.Response)
+			}
+			if res, ok := call.Result.(*EmbeddingsResponseBody); !ok || res.Model != TextEmbeddingAda002 {
+				t.Errorf("Result = %#v", call.Result)
+			}
+			return err
+		}
+	}, trace("inner"))
+
+	_, err := c.CreateEmbeddings(context.Background(), EmbeddingsRequestBody{
+		Model: TextEmbeddingAda002,
+		Input: "hello",
+	})
+	if err != nil {
+		t.Fatalf("create embeddings error: %v", err)
+	}
+	want := []string{"outer:before", "inner:before", "inner:after", "outer:after"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestClient_UseShortCircuit(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the server")
+	})
+	errDenied := errors.New("denied")
+	c.Use(func(next Handler) Handler {
+		return func(call *Call) error {
+			if call.Operation == "RetrieveModel" {
+				call.Result.(*ModelObject).ID = "cached"
+				return nil
+			}
+			return errDenied
+		}
+	})
+
+	model, err := c.RetrieveModel(context.Background(), GPT35Turbo)
+	if err != nil || model.ID != "cached" {
+		t.Errorf("RetrieveModel = %v, %v", model, err)
+	}
+	if _, err = c.ListModels(context.Background()); !errors.Is(err, errDenied) {
+		t.Errorf("ListModels error = %v, want %v", err, errDenied)
+	}
+}
@@ -0,0 +1,103 @@
+package openai
+
+import "time"
+
+// modelDate returns midnight UTC of a day.
+func modelDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+var (
+	chatEndpoints       = []Endpoint{EndpointChat}
+	completionEndpoints = []Endpoint{EndpointCompletions}
+	textModalities      = []Modality{ModalityText}
+	textImageModalities = []Modality{ModalityText, ModalityImage}
+
+	// shutdown of the legacy completions, edits and search models.
+	legacyShutdownDate = modelDate(2024, time.January, 4)
+)
+
+// builtinModels describes the OpenAI models. Prices are those of the
+// standard tier when the models were added.
+var builtinModels = []ModelInfo{
+	{ID: GPT4, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 8192, Pricing: Pricing{Input: 30, Output: 60}},
+	{ID: GPT40314, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 8192, Pricing: Pricing{Input: 30, Output: 60},
+		DeprecationDate: modelDate(2024, time.June, 13), Replacement: GPT4},
+	{ID: "gpt-4-0613", Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 8192, Pricing: Pricing{Input: 30, Output: 60}},
+	{ID: GPT432k, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 32768, Pricing: Pricing{Input: 60, Output: 120}},
+	{ID: GPT432k0314, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 32768, Pricing: Pricing{Input: 60, Output: 120},
+		DeprecationDate: modelDate(2024, time.June, 13), Replacement: GPT432k},
+	{ID: "gpt-4-32k-0613", Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 32768, Pricing: Pricing{Input: 60, Output: 120}},
+	{ID: "gpt-4-turbo-2024-04-09", Endpoints: chatEndpoints, Modalities: textImageModalities, ContextWindow: 128000,
+		MaxOutputTokens: 4096, Pricing: Pricing{Input: 10, Output: 30}},
+	{ID: "gpt-4o-2024-08-06", Endpoints: chatEndpoints, Modalities: textImageModalities, ContextWindow: 128000,
+		MaxOutputTokens: 16384, Pricing: Pricing{Input: 2.5, Output: 10}},
+	{ID: "gpt-4o-mini-2024-07-18", Endpoints: chatEndpoints, Modalities: textImageModalities, ContextWindow: 128000,
+		MaxOutputTokens: 16384, Pricing: Pricing{Input: 0.15, Output: 0.6}},
+	{ID: GPT35Turbo, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 16385, Pricing: Pricing{Input: 1.5, Output: 2}},
+	{ID: GPT35Turbo0301, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 4096, Pricing: Pricing{Input: 2, Output: 2},
+		DeprecationDate: modelDate(2024, time.June, 13), Replacement: GPT35Turbo},
+	{ID: GPT35Turbo0613, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 4096, Pricing: Pricing{Input: 1.5, Output: 2},
+		DeprecationDate: modelDate(2024, time.September, 13), Replacement: GPT35Turbo},
+	{ID: GPT35Turbo16k, Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 16385, Pricing: Pricing{Input: 3, Output: 4}},
+	{ID: "gpt-3.5-turbo-16k-0613", Endpoints: chatEndpoints, Modalities: textModalities, ContextWindow: 16385, Pricing: Pricing{Input: 3, Output: 4},
+		DeprecationDate: modelDate(2024, time.September, 13), Replacement: GPT35Turbo},
+	{ID: "gpt-3.5-turbo-instruct", Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 4096, Pricing: Pricing{Input: 1.5, Output: 2}},
+
+	{ID: TextDavinci003, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 4097, Pricing: Pricing{Input: 20, Output: 20},
+		DeprecationDate: legacyShutdownDate, Replacement: "gpt-3.5-turbo-instruct"},
+	{ID: TextDavinci002, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 4097, Pricing: Pricing{Input: 20, Output: 20},
+		DeprecationDate: legacyShutdownDate, Replacement: "gpt-3.5-turbo-instruct"},
+	{ID: TextCurie001, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 2, Output: 2},
+		DeprecationDate: legacyShutdownDate, Replacement: "gpt-3.5-turbo-instruct"},
+	{ID: TextBabBage001, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 0.5, Output: 0.5},
+		DeprecationDate: legacyShutdownDate, Replacement: "gpt-3.5-turbo-instruct"},
+	{ID: TextAda001, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 0.4, Output: 0.4},
+		DeprecationDate: legacyShutdownDate, Replacement: "gpt-3.5-turbo-instruct"},
+	{ID: Davinci, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 20, Output: 20},
+		DeprecationDate: legacyShutdownDate, Replacement: "davinci-002"},
+	{ID: Curie, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 2, Output: 2},
+		DeprecationDate: legacyShutdownDate, Replacement: "babbage-002"},
+	{ID: Babbage, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 0.5, Output: 0.5},
+		DeprecationDate: legacyShutdownDate, Replacement: "babbage-002"},
+	{ID: Ada, Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 2049, Pricing: Pricing{Input: 0.4, Output: 0.4},
+		DeprecationDate: legacyShutdownDate, Replacement: "babbage-002"},
+	{ID: "davinci-002", Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 16384, Pricing: Pricing{Input: 2, Output: 2}},
+	{ID: "babbage-002", Endpoints: completionEndpoints, Modalities: textModalities, ContextWindow: 16384, Pricing: Pricing{Input: 0.4, Output: 0.4}},
+
+	{ID: TextDavinciEdit001, Endpoints: []Endpoint{EndpointEdits}, Modalities: textModalities,
+		DeprecationDate: legacyShutdownDate, Replacement: GPT4},
+	{ID: CodeDavinciEdit001, Endpoints: []Endpoint{EndpointEdits}, Modalities: textModalities,
+		DeprecationDate: legacyShutdownDate, Replacement: GPT4},
+
+	{ID: TextEmbeddingAda002, Endpoints: []Endpoint{EndpointEmbeddings}, Modalities: textModalities, ContextWindow: 8191,
+		Pricing: Pricing{Input: 0.1}},
+	{ID: TextEmbedding3Small, Endpoints: []Endpoint{EndpointEmbeddings}, Modalities: textModalities, ContextWindow: 8191,
+		Pricing: Pricing{Input: 0.02}},
+	{ID: TextEmbedding3Large, Endpoints: []Endpoint{EndpointEmbeddings}, Modalities: textModalities, ContextWindow: 8191,
+		Pricing: Pricing{Input: 0.13}},
+	{ID: TextSearchAdaDoc001, Endpoints: []Endpoint{EndpointEmbeddings}, Modalities: textModalities, ContextWindow: 2046,
+		Pricing: Pricing{Input: 4}, DeprecationDate: legacyShutdownDate, Replacement: TextEmbeddingAda002},
+
+	{ID: Whisper1, Endpoints: []Endpoint{EndpointAudio}, Modalities: []Modality{ModalityAudio},
+		Pricing: Pricing{PerMinute: 0.006}},
+
+	{ID: TextModerationStable, Endpoints: []Endpoint{EndpointModerations}, Modalities: textModalities, ContextWindow: 32768},
+	{ID: TextModerationLatest, Endpoints: []Endpoint{EndpointModerations}, Modalities: textModalities, ContextWindow: 32768},
+	{ID: OmniModerationLatest, Endpoints: []Endpoint{EndpointModerations}, Modalities: textImageModalities},
+}
+
+// builtinAliases maps the model names that follow the latest snapshot to it.
+var builtinAliases = map[string]string{
+	GPT4Turbo: "gpt-4-turbo-2024-04-09",
+	GPT4o:     "gpt-4o-2024-08-06",
+	GPT4oMini: "gpt-4o-mini-2024-07-18",
+}
+
+func newDefaultModels() *ModelRegistry {
+	r := NewModelRegistry(builtinModels...)
+	for alias, target := range builtinAliases {
+		r.Alias(alias, target)
+	}
+	return r
+}
@@ -12,7 +12,12 @@ const (
 	GPT432k              = "gpt-4-32k"
 	GPT432k0314          = "gpt-4-32k-0314"
 	GPT35Turbo           = "gpt-3.5-turbo"
-	GPT35Turbo0310       = "gpt-3.5-turbo-0310"
+	GPT35Turbo0301       = "gpt-3.5-turbo-0301"
+	GPT35Turbo0613       = "gpt-3.5-turbo-0613"
+	GPT35Turbo16k        = "gpt-3.5-turbo-16k"
+	GPT4Turbo            = "gpt-4-turbo"
+	GPT4o                = "gpt-4o"
+	GPT4oMini            = "gpt-4o-mini"
 	TextDavinci003       = "text-davinci-003"
 	TextDavinci002       = "text-davinci-002"
 	TextCurie001         = "text-curie-001"
@@ -26,11 +31,19 @@ const (
 	CodeDavinciEdit001   = "code-davinci-edit-001"
 	Whisper1             = "whisper-1"
 	TextEmbeddingAda002  = "text-embedding-ada-002"
+	TextEmbedding3Small  = "text-embedding-3-small"
+	TextEmbedding3Large  = "text-embedding-3-large"
 	TextSearchAdaDoc001  = "text-search-ada-doc-001"
 	TextModerationStable = "text-moderation-stable"
 	TextModerationLatest = "text-moderation-latest"
+	OmniModerationLatest = "omni-moderation-latest"
 )
 
+// GPT35Turbo0310 names a snapshot that does not exist.
+//
+// Deprecated: use GPT35Turbo0301.
+const GPT35Turbo0310 = "gpt-3.5-turbo-0310"
+
 type ModelPermission struct {
 	ID                 string      `json:"id"`
 	Object             string      `json:"object"`
@@ -50,33 +63,40 @@ type ModelObject struct {
 	ID         string            `json:"id"`
 	Object     string            `json:"object"`
 	Created    int               `json:"created"`
-	OwnerBy    string            `json:"owner_by"`
+	OwnerBy    string            `json:"owned_by"`
 	Permission []ModelPermission `json:"permission"`
 	Root       string            `json:"root"`
 	Parent     interface{}       `json:"parent"`
+
+	ResponseMeta
 }
 
 type ModelsResponseBody struct {
 	Object string        `json:"object"`
 	Data   []ModelObject `json:"data"`
+
+	ResponseMeta
 }
 
 // ListModels Lists the currently available models, and provides basic information about each
 // one such as the owner and availability.
 // GET https://api.openai.com/v1/models
-func (c *Client) ListModels(ctx context.Context) (*ModelsResponseBody, error) {
+func (c *Client) ListModels(ctx context.Context, opts ...RequestOption) (*ModelsResponseBody, error) {
 	const apiURL = apiURLPrefix + "/v1/models"
-	req, err := c.newRequest(ctx, http.MethodGet, apiURL, nil)
-	if err != nil {
-		return nil, err
-	}
 	var body ModelsResponseBody
-	if err := c.getRequest(req, &body); err != nil {
+	if err := c.do(ctx, "ListModels", http.MethodGet, apiURL, nil, &body, opts...); err != nil {
 		return nil, err
 	}
 	return &body, nil
 }
 
+// PaginateModels Walks the currently available models page by page.
+// GET https://api.openai.com/v1/models
+func (c *Client) PaginateModels(params ListParams, opts ...RequestOption) *Paginator[ModelObject] {
+	const apiURL = apiURLPrefix + "/v1/models"
+	return paginate(c, "ListModels", apiURL, params, func(m ModelObject) string { return m.ID }, opts)
+}
+
 // RetrieveModel Retrieves a model instance, providing basic information about the model
 // such as the owner and permissioning.
 // `model`: The ID of the model to use for this request
@@ -84,13 +104,9 @@ func (c *Client) ListModels(ctx context.Context) (*ModelsResponseBody, error) {
 func (c *Client) RetrieveModel(
 	ctx context.Context,
 	model string,
+	opts ...RequestOption,
 ) (modelObject ModelObject, err error) {
 	var apiURL = fmt.Sprintf("%s/v1/models/%s", apiURLPrefix, model)
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodGet, apiURL, nil); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &modelObject)
+	err = c.do(ctx, "RetrieveModel", http.MethodGet, apiURL, nil, &modelObject, opts...)
 	return
 }
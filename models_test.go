@@ -13,8 +13,8 @@ func init() {
 }
 
 func TestClient_RetrieveModel(t *testing.T) {
-	c := NewClient(apiToken)
-	model, err := c.RetrieveModel(context.Background(), GPT35Turbo0310)
+	c := newCassetteClient(t, "retrieve_model")
+	model, err := c.RetrieveModel(context.Background(), GPT35Turbo)
 	if err != nil {
 		t.Fatalf("Retrieve model error: %v", err)
 	}
@@ -22,7 +22,7 @@ func TestClient_RetrieveModel(t *testing.T) {
 }
 
 func TestClient_ListModels(t *testing.T) {
-	c := NewClient(apiToken)
+	c := newCassetteClient(t, "list_models")
 	body, err := c.ListModels(context.Background())
 	if err != nil {
 		t.Fatalf("List models error: %v", err)
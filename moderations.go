@@ -10,8 +10,24 @@ import (
 // content policy.
 
 type ModerationRequestBody struct {
-	Input string
+	Input string `json:"input"`
 	Model string `json:"model,omitempty"`
+
+	// ExtraFields are merged into the body when it is sent.
+	ExtraFields map[string]any `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b ModerationRequestBody) MarshalJSON() ([]byte, error) {
+	type plain ModerationRequestBody
+	return marshalWithExtraFields(plain(b), b.ExtraFields)
+}
+
+// Validate implements Validator.
+func (b ModerationRequestBody) Validate() error {
+	var v validation
+	v.required("input", b.Input)
+	return v.err()
 }
 
 type ModerationObject struct {
@@ -24,9 +40,21 @@ type ModerationObject struct {
 	ViolenceGraphic bool `json:"violence/graphic"`
 }
 
+// ModerationScores are the confidences, between 0 and 1, that an input
+// violates each category.
+type ModerationScores struct {
+	Hate            float64 `json:"hate"`
+	HateThreatening float64 `json:"hate/threatening"`
+	SelfHarm        float64 `json:"self-harm"`
+	Sexual          float64 `json:"sexual"`
+	SexualMinors    float64 `json:"sexual/minors"`
+	Violence        float64 `json:"violence"`
+	ViolenceGraphic float64 `json:"violence/graphic"`
+}
+
 type ModerationResult struct {
 	Categories     ModerationObject `json:"categories"`
-	CategoryScores ModerationObject `json:"category_scores"`
+	CategoryScores ModerationScores `json:"category_scores"`
 	Flagged        bool             `json:"flagged"`
 }
 
@@ -34,20 +62,18 @@ type ModerationResponseBody struct {
 	ID      string             `json:"id"`
 	Model   string             `json:"model"`
 	Results []ModerationResult `json:"results"`
+
+	ResponseMeta
 }
 
 // CreateModeration Classifies if text violates OpenAI's Content Policy
 // POST https://api.openai.com/v1/moderations
 func (c *Client) CreateModeration(
 	ctx context.Context,
-	reqBody ModerationRequestBody) (resBody ModerationResponseBody, err error) {
+	reqBody ModerationRequestBody,
+	opts ...RequestOption) (resBody ModerationResponseBody, err error) {
 	const apiURL = apiURLPrefix + "/v1/moderations"
-	var req *http.Request
-	if req, err = c.newRequest(ctx, http.MethodPost, apiURL, reqBody); err != nil {
-		return
-	}
-
-	err = c.getRequest(req, &resBody)
+	err = c.do(ctx, "CreateModeration", http.MethodPost, apiURL, reqBody, &resBody, opts...)
 
 	return
 }
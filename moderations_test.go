@@ -1 +1,38 @@
 package openai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestClient_CreateModeration(t *testing.T) {
+	c := newCassetteClient(t, "create_moderation")
+	body, err := c.CreateModeration(context.Background(), ModerationRequestBody{
+		Input: "I want to kill them.",
+	})
+	if err != nil {
+		t.Fatalf("Create moderation error: %v", err)
+	}
+	if len(body.Results) != 1 || body.Results[0].CategoryScores.Violence == 0 {
+		t.Errorf("Create moderation = %+v", body)
+	}
+}
+
+func TestModerationResult_scores(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"modr-1","model":"omni-moderation-latest","results":[{"flagged":true,` +
+			`"categories":{"violence":true},"category_scores":{"violence":0.97,"hate":1.2e-05}}]}`))
+	})
+	body, err := c.CreateModeration(context.Background(), ModerationRequestBody{
+		Model: OmniModerationLatest,
+		Input: "I want to kill them.",
+	})
+	if err != nil {
+		t.Fatalf("Create moderation error: %v", err)
+	}
+	if scores := body.Results[0].CategoryScores; scores.Violence != 0.97 || scores.Hate != 1.2e-05 {
+		t.Errorf("CategoryScores = %+v", scores)
+	}
+}
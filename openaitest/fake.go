@@ -0,0 +1,235 @@
+package openaitest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+// ErrNotImplemented is returned by Fake methods whose function is not set.
+var ErrNotImplemented = errors.New("openaitest: fake method not implemented")
+
+// Fake implements openai.API with one function field per method, for unit
+// tests of code that depends on the API rather than on *openai.Client. Only
+// the functions a test needs have to be set; the others return
+// ErrNotImplemented. Fake records the name of every method called.
+type Fake struct {
+	CreateChatCompletionFunc func(context.Context, openai.ChatRequestBody, ...openai.RequestOption) (*openai.ChatResponseBody, error)
+	CreateCompletionsFunc    func(context.Context, openai.CompletionRequestBody, ...openai.RequestOption) (openai.CompletionResponseBody, error)
+	CreateEditFunc           func(context.Context, openai.EditRequestBody, ...openai.RequestOption) (openai.EditResponseBody, error)
+	CreateEmbeddingsFunc     func(context.Context, openai.EmbeddingsRequestBody, ...openai.RequestOption) (openai.EmbeddingsResponseBody, error)
+	CreateImageFunc          func(context.Context, openai.ImageRequestBody, ...openai.RequestOption) (openai.ImageResponseBody, error)
+	CreateImageEditFunc      func(context.Context, openai.ImageEditRequestBody, ...openai.RequestOption) (openai.ImageResponseBody, error)
+	CreateImageVariationFunc func(context.Context, openai.ImageVariationRequestBody, ...openai.RequestOption) (openai.ImageResponseBody, error)
+	CreateTranscriptionFunc  func(context.Context, openai.AudioRequestBody, ...openai.RequestOption) (openai.AudioResponseBody, error)
+	CreateTranslationFunc    func(context.Context, openai.AudioRequestBody, ...openai.RequestOption) (openai.AudioResponseBody, error)
+	ListFilesFunc            func(context.Context, ...openai.RequestOption) (openai.ListFilesResponseBody, error)
+	PaginateFilesFunc        func(openai.ListParams, ...openai.RequestOption) *openai.Paginator[openai.FileObject]
+	UploadFileFunc           func(context.Context, openai.UploadFileRequestBody, ...openai.RequestOption) (openai.FileObject, error)
+	DeleteFileFunc           func(context.Context, string, ...openai.RequestOption) (openai.DeleteFileResponseBody, error)
+	RetrieveFileFunc         func(context.Context, string, ...openai.RequestOption) (openai.FileObject, error)
+	RetrieveFileContentFunc  func(context.Context, string, ...openai.RequestOption) (openai.RetrieveFileContentResponseBody, error)
+	ListModelsFunc           func(context.Context, ...openai.RequestOption) (*openai.ModelsResponseBody, error)
+	PaginateModelsFunc       func(openai.ListParams, ...openai.RequestOption) *openai.Paginator[openai.ModelObject]
+	RetrieveModelFunc        func(context.Context, string, ...openai.RequestOption) (openai.ModelObject, error)
+	CreateModerationFunc     func(context.Context, openai.ModerationRequestBody, ...openai.RequestOption) (openai.ModerationResponseBody, error)
+
+	mu    sync.Mutex
+	calls []string
+}
+
+var _ openai.API = (*Fake)(nil)
+
+// Calls returns the names of the methods called so far, in order.
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *Fake) record(method string) {
+	f.mu.Lock()
+	f.calls = append(f.calls, method)
+	f.mu.Unlock()
+}
+
+// CreateChatCompletion calls CreateChatCompletionFunc.
+func (f *Fake) CreateChatCompletion(ctx context.Context, body openai.ChatRequestBody, opts ...openai.RequestOption) (*openai.ChatResponseBody, error) {
+	f.record("CreateChatCompletion")
+	if f.CreateChatCompletionFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return f.CreateChatCompletionFunc(ctx, body, opts...)
+}
+
+// CreateCompletions calls CreateCompletionsFunc.
+func (f *Fake) CreateCompletions(ctx context.Context, reqBody openai.CompletionRequestBody, opts ...openai.RequestOption) (openai.CompletionResponseBody, error) {
+	f.record("CreateCompletions")
+	if f.CreateCompletionsFunc == nil {
+		return openai.CompletionResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateCompletionsFunc(ctx, reqBody, opts...)
+}
+
+// CreateEdit calls CreateEditFunc.
+func (f *Fake) CreateEdit(ctx context.Context, reqBody openai.EditRequestBody, opts ...openai.RequestOption) (openai.EditResponseBody, error) {
+	f.record("CreateEdit")
+	if f.CreateEditFunc == nil {
+		return openai.EditResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateEditFunc(ctx, reqBody, opts...)
+}
+
+// CreateEmbeddings calls CreateEmbeddingsFunc.
+func (f *Fake) CreateEmbeddings(ctx context.Context, reqBody openai.EmbeddingsRequestBody, opts ...openai.RequestOption) (openai.EmbeddingsResponseBody, error) {
+	f.record("CreateEmbeddings")
+	if f.CreateEmbeddingsFunc == nil {
+		return openai.EmbeddingsResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateEmbeddingsFunc(ctx, reqBody, opts...)
+}
+
+// CreateImage calls CreateImageFunc.
+func (f *Fake) CreateImage(ctx context.Context, reqBody openai.ImageRequestBody, opts ...openai.RequestOption) (openai.ImageResponseBody, error) {
+	f.record("CreateImage")
+	if f.CreateImageFunc == nil {
+		return openai.ImageResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateImageFunc(ctx, reqBody, opts...)
+}
+
+// CreateImageEdit calls CreateImageEditFunc.
+func (f *Fake) CreateImageEdit(ctx context.Context, reqBody openai.ImageEditRequestBody, opts ...openai.RequestOption) (openai.ImageResponseBody, error) {
+	f.record("CreateImageEdit")
+	if f.CreateImageEditFunc == nil {
+		return openai.ImageResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateImageEditFunc(ctx, reqBody, opts...)
+}
+
+// CreateImageVariation calls CreateImageVariationFunc.
+func (f *Fake) CreateImageVariation(ctx context.Context, reqBody openai.ImageVariationRequestBody, opts ...openai.RequestOption) (openai.ImageResponseBody, error) {
+	f.record("CreateImageVariation")
+	if f.CreateImageVariationFunc == nil {
+		return openai.ImageResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateImageVariationFunc(ctx, reqBody, opts...)
+}
+
+// CreateTranscription calls CreateTranscriptionFunc.
+func (f *Fake) CreateTranscription(ctx context.Context, reqBody openai.AudioRequestBody, opts ...openai.RequestOption) (openai.AudioResponseBody, error) {
+	f.record("CreateTranscription")
+	if f.CreateTranscriptionFunc == nil {
+		return openai.AudioResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateTranscriptionFunc(ctx, reqBody, opts...)
+}
+
+// CreateTranslation calls CreateTranslationFunc.
+func (f *Fake) CreateTranslation(ctx context.Context, reqBody openai.AudioRequestBody, opts ...openai.RequestOption) (openai.AudioResponseBody, error) {
+	f.record("CreateTranslation")
+	if f.CreateTranslationFunc == nil {
+		return openai.AudioResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateTranslationFunc(ctx, reqBody, opts...)
+}
+
+// ListFiles calls ListFilesFunc.
+func (f *Fake) ListFiles(ctx context.Context, opts ...openai.RequestOption) (openai.ListFilesResponseBody, error) {
+	f.record("ListFiles")
+	if f.ListFilesFunc == nil {
+		return openai.ListFilesResponseBody{}, ErrNotImplemented
+	}
+	return f.ListFilesFunc(ctx, opts...)
+}
+
+// PaginateFiles calls PaginateFilesFunc.
+func (f *Fake) PaginateFiles(params openai.ListParams, opts ...openai.RequestOption) *openai.Paginator[openai.FileObject] {
+	f.record("PaginateFiles")
+	if f.PaginateFilesFunc == nil {
+		return notImplementedPaginator[openai.FileObject](params)
+	}
+	return f.PaginateFilesFunc(params, opts...)
+}
+
+// UploadFile calls UploadFileFunc.
+func (f *Fake) UploadFile(ctx context.Context, reqBody openai.UploadFileRequestBody, opts ...openai.RequestOption) (openai.FileObject, error) {
+	f.record("UploadFile")
+	if f.UploadFileFunc == nil {
+		return openai.FileObject{}, ErrNotImplemented
+	}
+	return f.UploadFileFunc(ctx, reqBody, opts...)
+}
+
+// DeleteFile calls DeleteFileFunc.
+func (f *Fake) DeleteFile(ctx context.Context, fileID string, opts ...openai.RequestOption) (openai.DeleteFileResponseBody, error) {
+	f.record("DeleteFile")
+	if f.DeleteFileFunc == nil {
+		return openai.DeleteFileResponseBody{}, ErrNotImplemented
+	}
+	return f.DeleteFileFunc(ctx, fileID, opts...)
+}
+
+// RetrieveFile calls RetrieveFileFunc.
+func (f *Fake) RetrieveFile(ctx context.Context, fileID string, opts ...openai.RequestOption) (openai.FileObject, error) {
+	f.record("RetrieveFile")
+	if f.RetrieveFileFunc == nil {
+		return openai.FileObject{}, ErrNotImplemented
+	}
+	return f.RetrieveFileFunc(ctx, fileID, opts...)
+}
+
+// RetrieveFileContent calls RetrieveFileContentFunc.
+func (f *Fake) RetrieveFileContent(ctx context.Context, fileID string, opts ...openai.RequestOption) (openai.RetrieveFileContentResponseBody, error) {
+	f.record("RetrieveFileContent")
+	if f.RetrieveFileContentFunc == nil {
+		return openai.RetrieveFileContentResponseBody{}, ErrNotImplemented
+	}
+	return f.RetrieveFileContentFunc(ctx, fileID, opts...)
+}
+
+// ListModels calls ListModelsFunc.
+func (f *Fake) ListModels(ctx context.Context, opts ...openai.RequestOption) (*openai.ModelsResponseBody, error) {
+	f.record("ListModels")
+	if f.ListModelsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return f.ListModelsFunc(ctx, opts...)
+}
+
+// PaginateModels calls PaginateModelsFunc.
+func (f *Fake) PaginateModels(params openai.ListParams, opts ...openai.RequestOption) *openai.Paginator[openai.ModelObject] {
+	f.record("PaginateModels")
+	if f.PaginateModelsFunc == nil {
+		return notImplementedPaginator[openai.ModelObject](params)
+	}
+	return f.PaginateModelsFunc(params, opts...)
+}
+
+// RetrieveModel calls RetrieveModelFunc.
+func (f *Fake) RetrieveModel(ctx context.Context, model string, opts ...openai.RequestOption) (openai.ModelObject, error) {
+	f.record("RetrieveModel")
+	if f.RetrieveModelFunc == nil {
+		return openai.ModelObject{}, ErrNotImplemented
+	}
+	return f.RetrieveModelFunc(ctx, model, opts...)
+}
+
+// CreateModeration calls CreateModerationFunc.
+func (f *Fake) CreateModeration(ctx context.Context, reqBody openai.ModerationRequestBody, opts ...openai.RequestOption) (openai.ModerationResponseBody, error) {
+	f.record("CreateModeration")
+	if f.CreateModerationFunc == nil {
+		return openai.ModerationResponseBody{}, ErrNotImplemented
+	}
+	return f.CreateModerationFunc(ctx, reqBody, opts...)
+}
+
+// notImplementedPaginator returns a Paginator whose first page fails with
+// ErrNotImplemented.
+func notImplementedPaginator[T any](params openai.ListParams) *openai.Paginator[T] {
+	return openai.NewPaginator(params, nil, func(context.Context, openai.ListParams) (*openai.Page[T], error) {
+		return nil, ErrNotImplemented
+	})
+}
@@ -0,0 +1,43 @@
+package openaitest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+// summarize stands for application code that only needs chat completions.
+func summarize(ctx context.Context, api openai.ChatAPI, text string) (string, error) {
+	res, err := api.CreateChatCompletion(ctx, openai.ChatRequestBody{
+		Model:    openai.GPT35Turbo,
+		Messages: []*openai.ChatMessage{{Role: openai.RoleUser, Content: "Summarize: " + text}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.Choices[0].Message.Content, nil
+}
+
+func TestFake(t *testing.T) {
+	fake := &Fake{
+		CreateChatCompletionFunc: func(ctx context.Context, body openai.ChatRequestBody, _ ...openai.RequestOption) (*openai.ChatResponseBody, error) {
+			return &openai.ChatResponseBody{Choices: []*openai.ChatChoice{
+				{Message: &openai.ChatMessage{Role: openai.RoleAssistant, Content: "short"}},
+			}}, nil
+		},
+	}
+
+	summary, err := summarize(context.Background(), fake, "a long text")
+	if err != nil || summary != "short" {
+		t.Errorf("summarize = %q, %v", summary, err)
+	}
+	if _, err = fake.ListModels(context.Background()); !errors.Is(err, ErrNotImplemented) {
+		t.Errorf("ListModels error = %v, want %v", err, ErrNotImplemented)
+	}
+	if got, want := fake.Calls(), []string{"CreateChatCompletion", "ListModels"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package openaitest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+// fileStore keeps uploaded files in memory.
+type fileStore struct {
+	mu       sync.Mutex
+	next     int
+	files    map[string]openai.FileObject
+	contents map[string][]byte
+}
+
+func newFileStore() *fileStore {
+	return &fileStore{
+		files:    make(map[string]openai.FileObject),
+		contents: make(map[string][]byte),
+	}
+}
+
+// AddFile stores a file as if it had been uploaded and returns its object.
+func (s *Server) AddFile(name, purpose string, content []byte) openai.FileObject {
+	return s.files.add(name, purpose, content)
+}
+
+func (f *fileStore) add(name, purpose string, content []byte) openai.FileObject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next++
+	file := openai.FileObject{
+		ID:        fmt.Sprintf("file-test%d", f.next),
+		Object:    "file",
+		Bytes:     len(content),
+		CreatedAt: Created,
+		FileName:  name,
+		Purpose:   purpose,
+	}
+	f.files[file.ID] = file
+	f.contents[file.ID] = content
+	return file
+}
+
+func (f *fileStore) list(w http.ResponseWriter, r *Request) {
+	f.mu.Lock()
+	files := make([]openai.FileObject, 0, len(f.files))
+	for _, file := range f.files {
+		files = append(files, file)
+	}
+	f.mu.Unlock()
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	res := listPage(r, files, func(file openai.FileObject) string { return file.ID })
+	writeJSON(w, http.StatusOK, &res)
+}
+
+// upload accepts multipart forms as well as the JSON body sent by
+// openai.Client.UploadFile, which only names the file.
+func (f *fileStore) upload(w http.ResponseWriter, r *Request) {
+	var (
+		name, purpose string
+		content       []byte
+	)
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		form, err := multipart.NewReader(bytes.NewReader(r.Body), params["boundary"]).ReadForm(32 << 20)
+		if err != nil {
+			Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, r)
+			return
+		}
+		if values := form.Value["purpose"]; len(values) > 0 {
+			purpose = values[0]
+		}
+		if headers := form.File["file"]; len(headers) > 0 {
+			name = headers[0].Filename
+			file, err := headers[0].Open()
+			if err == nil {
+				content, _ = io.ReadAll(file)
+				_ = file.Close()
+			}
+		}
+	} else {
+		var body openai.UploadFileRequestBody
+		if err := r.Decode(&body); err != nil {
+			Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, r)
+			return
+		}
+		name, purpose = filepath.Base(body.File), body.Purpose
+	}
+	if name == "" || purpose == "" {
+		Error(http.StatusBadRequest, "'file' and 'purpose' are required", "invalid_request_error")(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, f.add(name, purpose, content))
+}
+
+func (f *fileStore) get(w http.ResponseWriter, r *Request) (openai.FileObject, bool) {
+	f.mu.Lock()
+	file, ok := f.files[r.Params["file_id"]]
+	f.mu.Unlock()
+	if !ok {
+		Error(http.StatusNotFound, fmt.Sprintf("No such File object: %s", r.Params["file_id"]),
+			"invalid_request_error")(w, r)
+	}
+	return file, ok
+}
+
+func (f *fileStore) retrieve(w http.ResponseWriter, r *Request) {
+	if file, ok := f.get(w, r); ok {
+		writeJSON(w, http.StatusOK, file)
+	}
+}
+
+func (f *fileStore) content(w http.ResponseWriter, r *Request) {
+	if file, ok := f.get(w, r); ok {
+		f.mu.Lock()
+		content := f.contents[file.ID]
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(content)
+	}
+}
+
+func (f *fileStore) delete(w http.ResponseWriter, r *Request) {
+	file, ok := f.get(w, r)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	delete(f.files, file.ID)
+	delete(f.contents, file.ID)
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, &openai.DeleteFileResponseBody{ID: file.ID, Object: "file", Deleted: true})
+}
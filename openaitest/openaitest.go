@@ -0,0 +1,285 @@
+// Package openaitest provides an in-process fake of the OpenAI API for tests.
+//
+// A Server answers every endpoint covered by openai.Client with plausible,
+// deterministic responses. Tests can script responses per route, inject
+// errors, and inspect the requests the server received:
+//
+//	srv := openaitest.NewServer()
+//	defer srv.Close()
+//	srv.Enqueue("POST /v1/chat/completions",
+//		openaitest.RateLimited(time.Second),
+//		openaitest.ChatReply("Hello!"))
+//	c := srv.Client()
+package openaitest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+// Routes served by the Server. Path parameters are written in braces.
+const (
+	RouteChatCompletions     = "POST /v1/chat/completions"
+	RouteCompletions         = "POST /v1/completions"
+	RouteEdits               = "POST /v1/edits"
+	RouteEmbeddings          = "POST /v1/embeddings"
+	RouteModerations         = "POST /v1/moderations"
+	RouteImageGenerations    = "POST /v1/images/generations"
+	RouteImageEdits          = "POST /v1/images/edits"
+	RouteImageVariations     = "POST /v1/images/variations"
+	RouteAudioTranscriptions = "POST /v1/audio/transcriptions"
+	RouteAudioTranslations   = "POST /v1/audio/translations"
+	RouteListFiles           = "GET /v1/files"
+	RouteUploadFile          = "POST /v1/files"
+	RouteRetrieveFile        = "GET /v1/files/{file_id}"
+	RouteDeleteFile          = "DELETE /v1/files/{file_id}"
+	RouteFileContent         = "GET /v1/files/{file_id}/content"
+	RouteListModels          = "GET /v1/models"
+	RouteRetrieveModel       = "GET /v1/models/{model}"
+)
+
+// Request is a request received by the Server.
+type Request struct {
+	// Route is the route the request matched, e.g. RouteChatCompletions.
+	Route  string
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+	// Params holds the path parameters of the route.
+	Params map[string]string
+}
+
+// Decode unmarshals the JSON body of the request into v.
+func (r *Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Responder writes the response to a request.
+type Responder func(w http.ResponseWriter, r *Request)
+
+// Server is a fake OpenAI API server. It is safe for concurrent use.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	requests []*Request
+	queues   map[string][]Responder
+	handlers map[string]Responder
+	files    *fileStore
+}
+
+// NewServer starts a Server. Callers should Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		queues: make(map[string][]Responder),
+		files:  newFileStore(),
+	}
+	s.handlers = map[string]Responder{
+		RouteChatCompletions:     chatCompletions,
+		RouteCompletions:         completions,
+		RouteEdits:               edits,
+		RouteEmbeddings:          embeddings,
+		RouteModerations:         moderations,
+		RouteImageGenerations:    images,
+		RouteImageEdits:          images,
+		RouteImageVariations:     images,
+		RouteAudioTranscriptions: audio,
+		RouteAudioTranslations:   audio,
+		RouteListFiles:           s.files.list,
+		RouteUploadFile:          s.files.upload,
+		RouteRetrieveFile:        s.files.retrieve,
+		RouteDeleteFile:          s.files.delete,
+		RouteFileContent:         s.files.content,
+		RouteListModels:          listModels,
+		RouteRetrieveModel:       retrieveModel,
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL returns the base URL of the server.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns an openai.Client that sends its requests to the server.
+func (s *Server) Client() *openai.Client {
+	c := openai.NewClient("sk-test")
+	c.HTTPClient = s.HTTPClient()
+	return c
+}
+
+// HTTPClient returns an http.Client that redirects requests for the API to
+// the server, for use as openai.Client.HTTPClient.
+func (s *Server) HTTPClient() *http.Client {
+	target, _ := url.Parse(s.srv.URL)
+	return &http.Client{Transport: redirectTransport{target: target, next: s.srv.Client().Transport}}
+}
+
+// Handle replaces the default responder of a route, or adds a route. A route
+// with literal segments, such as "GET /v1/models/gpt-4", takes precedence over
+// one with parameters in their place.
+func (s *Server) Handle(route string, r Responder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[route] = r
+}
+
+// Enqueue scripts responses for a route. Each request to the route consumes
+// the next responder; once the queue is empty the default responder is used
+// again.
+func (s *Server) Enqueue(route string, responders ...Responder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues[route] = append(s.queues[route], responders...)
+}
+
+// Requests returns the requests received so far, optionally only those that
+// matched one of routes.
+func (s *Server) Requests(routes ...string) []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(routes) == 0 {
+		return append([]*Request(nil), s.requests...)
+	}
+	var requests []*Request
+	for _, r := range s.requests {
+		for _, route := range routes {
+			if r.Route == route {
+				requests = append(requests, r)
+			}
+		}
+	}
+	return requests
+}
+
+// LastRequest returns the most recent request, or nil when none was received.
+func (s *Server) LastRequest() *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+// Reset forgets the received requests and the scripted responses.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.queues = make(map[string][]Responder)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, hr *http.Request) {
+	body, err := io.ReadAll(hr.Body)
+	if err != nil {
+		Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, nil)
+		return
+	}
+	r := &Request{
+		Method: hr.Method,
+		Path:   hr.URL.Path,
+		Query:  hr.URL.Query(),
+		Header: hr.Header.Clone(),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+	r.Route, r.Params = s.route(hr.Method, hr.URL.Path)
+	responder := s.handlers[r.Route]
+	s.requests = append(s.requests, r)
+	if queue := s.queues[r.Route]; len(queue) > 0 {
+		responder = queue[0]
+		s.queues[r.Route] = queue[1:]
+	}
+	s.mu.Unlock()
+
+	if responder == nil {
+		Error(http.StatusNotFound, "Invalid URL ("+hr.Method+" "+hr.URL.Path+")", "invalid_request_error")(w, r)
+		return
+	}
+	if !strings.HasPrefix(hr.Header.Get("Authorization"), "Bearer ") {
+		Error(http.StatusUnauthorized, "You didn't provide an API key.", "invalid_request_error")(w, r)
+		return
+	}
+	responder(w, r)
+}
+
+// route returns the route matching method and path, with its path
+// parameters. When several match, the most specific wins, so that a route
+// such as "GET /v1/models/gpt-4" is preferred to RouteRetrieveModel: the one
+// with the fewest parameters, then the first in lexical order. Routes with
+// queued responders match even without a handler.
+func (s *Server) route(method, path string) (string, map[string]string) {
+	var (
+		best       string
+		bestParams map[string]string
+	)
+	consider := func(route string) {
+		params, ok := matchRoute(route, method, path)
+		if ok && (best == "" || len(params) < len(bestParams) || len(params) == len(bestParams) && route < best) {
+			best, bestParams = route, params
+		}
+	}
+	for route := range s.handlers {
+		consider(route)
+	}
+	for route, queue := range s.queues {
+		if len(queue) > 0 {
+			consider(route)
+		}
+	}
+	return best, bestParams
+}
+
+// matchRoute reports whether method and path match route, returning the
+// values of its path parameters.
+func matchRoute(route, method, path string) (map[string]string, bool) {
+	routeMethod, routePath, _ := strings.Cut(route, " ")
+	if routeMethod != method {
+		return nil, false
+	}
+	want := strings.Split(strings.Trim(routePath, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[strings.Trim(segment, "{}")] = got[i]
+		} else if segment != got[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// redirectTransport sends every request to the fake server.
+type redirectTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	req.Host = t.target.Host
+	return t.next.RoundTrip(req)
+}
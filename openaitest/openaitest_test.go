@@ -0,0 +1,236 @@
+package openaitest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+func TestServer_Chat(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	res, err := c.CreateChatCompletion(ctx, openai.ChatRequestBody{
+		Model:    openai.GPT35Turbo,
+		Messages: []*openai.ChatMessage{{Role: openai.RoleUser, Content: "ping"}},
+	})
+	if err != nil {
+		t.Fatalf("create chat completion error: %v", err)
+	}
+	if got := res.Choices[0].Message.Content; got != "echo: ping" {
+		t.Errorf("content = %q, want %q", got, "echo: ping")
+	}
+
+	// Null messages, which only an unvalidated body holds, are ignored.
+	res, err = c.CreateChatCompletion(ctx, openai.ChatRequestBody{
+		Model:    openai.GPT35Turbo,
+		Messages: []*openai.ChatMessage{nil, {Role: openai.RoleUser, Content: "ping"}, nil},
+	}, openai.WithoutValidation())
+	if err != nil || res.Choices[0].Message.Content != "echo: ping" {
+		t.Fatalf("chat completion with null messages = %+v, %v", res, err)
+	}
+
+	srv.Enqueue(RouteChatCompletions, ChatReply("Hello there, friend"))
+	res, err = c.CreateChatCompletion(ctx, openai.ChatRequestBody{
+		Model:    openai.GPT35Turbo,
+		Stream:   true,
+		Messages: []*openai.ChatMessage{{Role: openai.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("create chat completion error: %v", err)
+	}
+	var content strings.Builder
+	for chunk := range res.StreamChan {
+		if delta := chunk.Choices[0].Delta; delta != nil {
+			content.WriteString(delta.Content)
+		}
+	}
+	if content.String() != "Hello there, friend" {
+		t.Errorf("streamed content = %q", content.String())
+	}
+
+	requests := srv.Requests(RouteChatCompletions)
+	if len(requests) != 3 {
+		t.Fatalf("got %d chat requests, want 3", len(requests))
+	}
+	var body openai.ChatRequestBody
+	if err = requests[2].Decode(&body); err != nil || !body.Stream {
+		t.Errorf("second request = %+v, %v", body, err)
+	}
+	if got := requests[0].Header.Get("Authorization"); got != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+	srv.Enqueue(RouteListModels, RateLimited(2*time.Second), InternalError(), MalformedJSON())
+
+	var apiErr *openai.Error
+	if _, err := c.ListModels(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("first error = %v, want 429", err)
+	}
+	if _, err := c.ListModels(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("second error = %v, want 500", err)
+	}
+	if _, err := c.ListModels(ctx); err == nil {
+		t.Error("malformed JSON did not fail")
+	}
+	if res, err := c.ListModels(ctx); err != nil || len(res.Data) == 0 {
+		t.Errorf("default response = %v, %v", res, err)
+	}
+	if _, err := c.RetrieveModel(ctx, "no-such-model"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown model error = %v, want 404", err)
+	}
+}
+
+func TestServer_Embeddings(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	embed := func(input string) []float64 {
+		res, err := c.CreateEmbeddings(context.Background(), openai.EmbeddingsRequestBody{
+			Model: openai.TextEmbeddingAda002,
+			Input: input,
+		})
+		if err != nil {
+			t.Fatalf("create embeddings error: %v", err)
+		}
+		return res.Data[0].Embedding
+	}
+	a, b, other := embed("hello"), embed("hello"), embed("world")
+	if len(a) != EmbeddingDimensions {
+		t.Fatalf("got %d dimensions, want %d", len(a), EmbeddingDimensions)
+	}
+	var same, different float64
+	for i := range a {
+		same += a[i] * b[i]
+		different += a[i] * other[i]
+	}
+	if same < 0.999 || same > 1.001 {
+		t.Errorf("similarity of equal inputs = %f, want 1", same)
+	}
+	if different > 0.5 {
+		t.Errorf("similarity of different inputs = %f", different)
+	}
+}
+
+func TestServer_Files(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	existing := srv.AddFile("train.jsonl", "fine-tune", []byte(`{"prompt":"a","completion":"b"}`))
+	uploaded, err := c.UploadFile(ctx, openai.UploadFileRequestBody{File: "data/more.jsonl", Purpose: "fine-tune"})
+	if err != nil || uploaded.FileName != "more.jsonl" {
+		t.Fatalf("upload file = %+v, %v", uploaded, err)
+	}
+	list, err := c.ListFiles(ctx)
+	if err != nil || len(list.Data) != 2 {
+		t.Fatalf("list files = %+v, %v", list, err)
+	}
+	content, err := c.RetrieveFileContent(ctx, existing.ID)
+	if err != nil || string(content.Data) != `{"prompt":"a","completion":"b"}` {
+		t.Errorf("file content = %q, %v", content.Data, err)
+	}
+	if deleted, err := c.DeleteFile(ctx, existing.ID); err != nil || !deleted.Deleted {
+		t.Errorf("delete file = %+v, %v", deleted, err)
+	}
+	if _, err = c.RetrieveFile(ctx, existing.ID); err == nil {
+		t.Error("deleted file can still be retrieved")
+	}
+}
+
+func TestServer_OtherEndpoints(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	if res, err := c.CreateModeration(ctx, openai.ModerationRequestBody{Input: "this is flagged"}); err != nil ||
+		!res.Results[0].Flagged {
+		t.Errorf("moderation = %+v, %v", res, err)
+	}
+	if res, err := c.CreateImage(ctx, openai.ImageRequestBody{Prompt: "otter", N: 2}); err != nil || len(res.Data) != 2 {
+		t.Errorf("image = %+v, %v", res, err)
+	}
+	if res, err := c.CreateTranscription(ctx, openai.AudioRequestBody{File: "../testdata/silence.wav", Model: openai.Whisper1}); err != nil ||
+		res.Text == "" {
+		t.Errorf("transcription = %+v, %v", res, err)
+	}
+	if res, err := c.CreateEdit(ctx, openai.EditRequestBody{
+		Model:       openai.TextDavinciEdit001,
+		Input:       "text",
+		Instruction: "keep it",
+	}); err != nil || res.Choices[0].Text != "text" {
+		t.Errorf("edit = %+v, %v", res, err)
+	}
+	if got := srv.LastRequest().Route; got != RouteEdits {
+		t.Errorf("last route = %q, want %q", got, RouteEdits)
+	}
+}
+
+func TestServer_Pagination(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	for i := 0; i < 5; i++ {
+		srv.AddFile("data.jsonl", "fine-tune", nil)
+	}
+
+	files, err := c.PaginateFiles(openai.ListParams{Limit: 2}).All(context.Background())
+	if err != nil || len(files) != 5 {
+		t.Fatalf("paginate files = %d files, %v", len(files), err)
+	}
+	if got := len(srv.Requests(RouteListFiles)); got != 3 {
+		t.Errorf("got %d list requests, want 3", got)
+	}
+	if got := srv.LastRequest().Query.Get("after"); got != files[3].ID {
+		t.Errorf("last cursor = %q, want %q", got, files[3].ID)
+	}
+
+	models, err := c.PaginateModels(openai.ListParams{}).All(context.Background())
+	if err != nil || len(models) == 0 {
+		t.Errorf("paginate models = %v, %v", models, err)
+	}
+}
+
+func TestServer_routePriority(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ctx := context.Background()
+
+	// An exact route wins over the route with a parameter, every time.
+	srv.Handle("GET /v1/models/gpt-4", JSON(http.StatusOK, openai.ModelObject{ID: "exact"}))
+	srv.Enqueue("GET /v1/models/gpt-4-32k", InternalError())
+	for i := 0; i < 20; i++ {
+		if model, err := c.RetrieveModel(ctx, openai.GPT4); err != nil || model.ID != "exact" {
+			t.Fatalf("RetrieveModel(gpt-4) = %+v, %v", model, err)
+		}
+		if model, err := c.RetrieveModel(ctx, openai.GPT35Turbo); err != nil || model.ID != openai.GPT35Turbo {
+			t.Fatalf("RetrieveModel(gpt-3.5-turbo) = %+v, %v", model, err)
+		}
+	}
+	if _, err := c.RetrieveModel(ctx, openai.GPT432k); err == nil {
+		t.Error("queued responder of an exact route was not used")
+	}
+	if _, err := c.RetrieveModel(ctx, openai.GPT432k); err != nil {
+		t.Errorf("RetrieveModel(gpt-4-32k) once the queue is empty: %v", err)
+	}
+	if got := srv.LastRequest().Route; got != RouteRetrieveModel {
+		t.Errorf("last route = %q, want %q once the queue is empty", got, RouteRetrieveModel)
+	}
+}
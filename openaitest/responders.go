@@ -0,0 +1,345 @@
+package openaitest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+// Created is the timestamp of every object returned by the Server.
+const Created = 1677610602
+
+// EmbeddingDimensions is the length of the vectors returned by the default
+// embeddings responder.
+const EmbeddingDimensions = 1536
+
+// JSON responds with v encoded as JSON.
+func JSON(status int, v any) Responder {
+	return func(w http.ResponseWriter, r *Request) {
+		writeJSON(w, status, v)
+	}
+}
+
+// Error responds with an API error of the given status.
+func Error(status int, message, errType string) Responder {
+	return JSON(status, openai.ErrorResponseBody{Error: &openai.Error{
+		Message: message,
+		Type:    errType,
+	}})
+}
+
+// RateLimited responds with 429 Too Many Requests and a Retry-After header.
+func RateLimited(retryAfter time.Duration) Responder {
+	return func(w http.ResponseWriter, r *Request) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		Error(http.StatusTooManyRequests, "Rate limit reached for requests", "requests")(w, r)
+	}
+}
+
+// InternalError responds with 500 Internal Server Error.
+func InternalError() Responder {
+	return Error(http.StatusInternalServerError,
+		"The server had an error while processing your request. Sorry about that!", "server_error")
+}
+
+// MalformedJSON responds with 200 OK and a body that is not valid JSON.
+func MalformedJSON() Responder {
+	return func(w http.ResponseWriter, r *Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "chatcmpl-truncated", "choices": [`))
+	}
+}
+
+// ChatReply responds to a chat completion with content as the assistant
+// message, streamed word by word when the request asks for a stream.
+func ChatReply(content string) Responder {
+	return func(w http.ResponseWriter, r *Request) {
+		var body openai.ChatRequestBody
+		_ = r.Decode(&body)
+		if body.Stream {
+			Stream(content)(w, r)
+			return
+		}
+		stop := "stop"
+		writeJSON(w, http.StatusOK, &openai.ChatResponseBody{
+			ID:      "chatcmpl-test",
+			Object:  "chat.completion",
+			Created: Created,
+			Choices: []*openai.ChatChoice{{
+				Message:      &openai.ChatMessage{Role: openai.RoleAssistant, Content: content},
+				FinishReason: &stop,
+			}},
+			Usage: usage(chatPrompt(body), content),
+		})
+	}
+}
+
+// Stream responds with a chat completion stream delivering content word by
+// word, followed by a stop chunk and the [DONE] marker.
+func Stream(content string) Responder {
+	return func(w http.ResponseWriter, r *Request) {
+		chunk := func(delta *openai.ChatMessage, finishReason *string) *openai.ChatStreamChunk {
+			return &openai.ChatStreamChunk{
+				ID:      "chatcmpl-test",
+				Object:  "chat.completion.chunk",
+				Created: Created,
+				Choices: []*openai.ChatChoice{{Delta: delta, FinishReason: finishReason}},
+			}
+		}
+		chunks := []*openai.ChatStreamChunk{chunk(&openai.ChatMessage{Role: openai.RoleAssistant}, nil)}
+		for _, word := range strings.SplitAfter(content, " ") {
+			if word != "" {
+				chunks = append(chunks, chunk(&openai.ChatMessage{Content: word}, nil))
+			}
+		}
+		stop := "stop"
+		chunks = append(chunks, chunk(&openai.ChatMessage{}, &stop))
+		StreamChunks(chunks...)(w, r)
+	}
+}
+
+// StreamChunks responds with a server-sent event stream of the given chunks,
+// followed by the [DONE] marker.
+func StreamChunks(chunks ...*openai.ChatStreamChunk) Responder {
+	return func(w http.ResponseWriter, r *Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher, _ := w.(http.Flusher)
+		for _, chunk := range chunks {
+			data, _ := json.Marshal(chunk)
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+// Embedding returns a deterministic unit vector of the given dimensions for
+// input. Equal inputs always produce equal vectors.
+func Embedding(input string, dimensions int) []float64 {
+	vector := make([]float64, dimensions)
+	var norm float64
+	var block [sha256.Size]byte
+	for i := range vector {
+		if i%(sha256.Size/4) == 0 {
+			block = sha256.Sum256([]byte(input + "#" + strconv.Itoa(i)))
+		}
+		offset := i % (sha256.Size / 4) * 4
+		v := float64(binary.LittleEndian.Uint32(block[offset:]))/math.MaxUint32*2 - 1
+		vector[i] = v
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// countTokens approximates the token count of s by its number of words.
+func countTokens(s string) int {
+	return len(strings.Fields(s))
+}
+
+func usage(prompt, completion string) openai.TokensUsage {
+	u := openai.TokensUsage{
+		PromptTokens:     countTokens(prompt),
+		CompletionTokens: countTokens(completion),
+	}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
+
+func chatPrompt(body openai.ChatRequestBody) string {
+	var sb strings.Builder
+	for _, m := range body.Messages {
+		if m == nil {
+			continue
+		}
+		sb.WriteString(m.Content)
+		sb.WriteString(" ")
+	}
+	return sb.String()
+}
+
+// chatCompletions echoes the last message of the conversation, ignoring null
+// messages.
+func chatCompletions(w http.ResponseWriter, r *Request) {
+	var body openai.ChatRequestBody
+	var last *openai.ChatMessage
+	if err := r.Decode(&body); err == nil {
+		for _, m := range body.Messages {
+			if m != nil {
+				last = m
+			}
+		}
+	}
+	if last == nil {
+		Error(http.StatusBadRequest, "'messages' is a required property", "invalid_request_error")(w, r)
+		return
+	}
+	ChatReply("echo: "+last.Content)(w, r)
+}
+
+// completions echoes the prompt.
+func completions(w http.ResponseWriter, r *Request) {
+	var body openai.CompletionRequestBody
+	if err := r.Decode(&body); err != nil {
+		Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, r)
+		return
+	}
+	text := "echo: " + body.Prompt
+	writeJSON(w, http.StatusOK, &openai.CompletionResponseBody{
+		ID:      "cmpl-test",
+		Object:  "text_completion",
+		Created: Created,
+		Model:   body.Model,
+		Choices: []openai.CompletionChoice{{Text: text, FinishReason: "stop"}},
+		Usage:   usage(body.Prompt, text),
+	})
+}
+
+// edits returns the input unchanged.
+func edits(w http.ResponseWriter, r *Request) {
+	var body openai.EditRequestBody
+	if err := r.Decode(&body); err != nil {
+		Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, &openai.EditResponseBody{
+		Object:  "edit",
+		Created: Created,
+		Choices: []openai.EditChoice{{Text: body.Input}},
+		Usage:   usage(body.Input+" "+body.Instruction, body.Input),
+	})
+}
+
+func embeddings(w http.ResponseWriter, r *Request) {
+	var body openai.EmbeddingsRequestBody
+	if err := r.Decode(&body); err != nil {
+		Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, r)
+		return
+	}
+	res := openai.EmbeddingsResponseBody{
+		Object: "list",
+		Model:  body.Model,
+		Usage:  usage(body.Input, ""),
+	}
+	res.Data = append(res.Data, struct {
+		Object    string    `json:"object"`
+		Embedding []float64 `json:"embedding"`
+	}{Object: "embedding", Embedding: Embedding(body.Input, EmbeddingDimensions)})
+	writeJSON(w, http.StatusOK, &res)
+}
+
+// moderations flags inputs containing the word "flagged".
+func moderations(w http.ResponseWriter, r *Request) {
+	var body openai.ModerationRequestBody
+	if err := r.Decode(&body); err != nil {
+		Error(http.StatusBadRequest, err.Error(), "invalid_request_error")(w, r)
+		return
+	}
+	model := body.Model
+	if model == "" {
+		model = openai.TextModerationLatest
+	}
+	flagged := strings.Contains(strings.ToLower(body.Input), "flagged")
+	writeJSON(w, http.StatusOK, &openai.ModerationResponseBody{
+		ID:    "modr-test",
+		Model: model,
+		Results: []openai.ModerationResult{{
+			Categories: openai.ModerationObject{Violence: flagged},
+			Flagged:    flagged,
+		}},
+	})
+}
+
+// images returns as many URLs as requested. Multipart requests of image
+// edits and variations always get one.
+func images(w http.ResponseWriter, r *Request) {
+	var body openai.ImageRequestBody
+	_ = r.Decode(&body)
+	n := body.N
+	if n < 1 {
+		n = 1
+	}
+	res := openai.ImageResponseBody{Created: Created}
+	for i := 0; i < n; i++ {
+		res.Data = append(res.Data, openai.ImageData{URL: fmt.Sprintf("https://images.example.com/img-%d.png", i)})
+	}
+	writeJSON(w, http.StatusOK, &res)
+}
+
+func audio(w http.ResponseWriter, r *Request) {
+	writeJSON(w, http.StatusOK, &openai.AudioResponseBody{Text: "This is a fake transcription."})
+}
+
+var models = []string{
+	openai.GPT4, openai.GPT40314, openai.GPT432k, openai.GPT432k0314,
+	openai.GPT35Turbo, openai.GPT35Turbo0301, openai.TextDavinci003,
+	openai.TextDavinciEdit001, openai.Whisper1, openai.TextEmbeddingAda002,
+	openai.TextModerationStable, openai.TextModerationLatest,
+}
+
+func modelObject(id string) openai.ModelObject {
+	return openai.ModelObject{ID: id, Object: "model", Created: Created, OwnerBy: "openai", Root: id}
+}
+
+func listModels(w http.ResponseWriter, r *Request) {
+	var data []openai.ModelObject
+	for _, id := range models {
+		data = append(data, modelObject(id))
+	}
+	res := listPage(r, data, func(m openai.ModelObject) string { return m.ID })
+	writeJSON(w, http.StatusOK, &res)
+}
+
+func retrieveModel(w http.ResponseWriter, r *Request) {
+	id := r.Params["model"]
+	for _, m := range models {
+		if m == id {
+			writeJSON(w, http.StatusOK, modelObject(id))
+			return
+		}
+	}
+	Error(http.StatusNotFound, fmt.Sprintf("The model '%s' does not exist", id), "invalid_request_error")(w, r)
+}
+
+// listPage returns the page of items selected by the limit and after query
+// parameters of a list request, all of them by default.
+func listPage[T any](r *Request, items []T, id func(T) string) openai.Page[T] {
+	if after := r.Query.Get("after"); after != "" {
+		for i, item := range items {
+			if id(item) == after {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	page := openai.Page[T]{Object: "list", Data: items}
+	if limit, err := strconv.Atoi(r.Query.Get("limit")); err == nil && limit > 0 && limit < len(items) {
+		page.Data, page.HasMore = items[:limit], true
+	}
+	if len(page.Data) > 0 {
+		page.FirstID, page.LastID = id(page.Data[0]), id(page.Data[len(page.Data)-1])
+	} else {
+		page.Data = []T{}
+	}
+	return page
+}
@@ -0,0 +1,173 @@
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequestOption customizes a single call. Options are passed as trailing
+// arguments to any Client method and applied in order.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	header      http.Header
+	query       url.Values
+	extraFields map[string]any
+	baseURL     string
+	timeout     time.Duration
+	retry       *RetryPolicy
+
+	skipValidation bool
+}
+
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{
+		header: make(http.Header),
+		query:  make(url.Values),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithHeader sets a header on the request, replacing any value set by the
+// client.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// WithQuery adds a query parameter to the request URL.
+func WithQuery(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.query.Add(key, value)
+	}
+}
+
+// WithExtraField adds a field to the request body, for API parameters the
+// request types do not model yet. It overrides a field of the same name.
+// Multipart bodies receive it as a form field.
+func WithExtraField(key string, value any) RequestOption {
+	return func(o *requestOptions) {
+		if o.extraFields == nil {
+			o.extraFields = make(map[string]any)
+		}
+		o.extraFields[key] = value
+	}
+}
+
+// WithBaseURL sends the request to baseURL instead of https://api.openai.com,
+// e.g. "https://proxy.example.com/openai". The endpoint path is appended to it.
+func WithBaseURL(baseURL string) RequestOption {
+	return func(o *requestOptions) {
+		o.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTimeout bounds the duration of the call, including retries. For
+// streams it also bounds reading the stream.
+func WithTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header, so that a retried
+// request is not processed twice.
+func WithIdempotencyKey(key string) RequestOption {
+	return WithHeader("Idempotency-Key", key)
+}
+
+// WithRetry overrides Client.Retry for the call.
+func WithRetry(policy RetryPolicy) RequestOption {
+	return func(o *requestOptions) {
+		o.retry = &policy
+	}
+}
+
+// url applies the base URL override and query parameters to an endpoint URL.
+func (o *requestOptions) url(endpoint string) (string, error) {
+	if o.baseURL != "" {
+		endpoint = o.baseURL + strings.TrimPrefix(endpoint, apiURLPrefix)
+	}
+	if len(o.query) == 0 {
+		return endpoint, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for key, values := range o.query {
+		query[key] = append(query[key], values...)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// encodeJSON writes body to w as JSON with the extra fields merged into it.
+func encodeJSON(w io.Writer, body any, extraFields map[string]any) error {
+	if len(extraFields) == 0 {
+		return json.NewEncoder(w).Encode(body)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if data, err = mergeFields(data, extraFields); err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// mergeExtraFields returns the extra fields of a body with those of the
+// request options, which override fields of the same name.
+func mergeExtraFields(fields, overrides map[string]any) map[string]any {
+	if len(overrides) == 0 {
+		return fields
+	}
+	merged := make(map[string]any, len(fields)+len(overrides))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
+// writeFormField writes a field of a multipart body, unless an extra field of
+// the same name overrides it.
+func writeFormField(w *multipart.Writer, extraFields map[string]any, name, value string) error {
+	if _, ok := extraFields[name]; ok {
+		return nil
+	}
+	return w.WriteField(name, value)
+}
+
+// writeExtraFields writes the extra fields as form fields, formatting values
+// other than strings as JSON.
+func writeExtraFields(w *multipart.Writer, extraFields map[string]any) error {
+	for key, value := range extraFields {
+		s, ok := value.(string)
+		if !ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			s = string(data)
+		}
+		if err := w.WriteField(key, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
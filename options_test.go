@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequestOptions(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/v1/embeddings" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2023-05-15" {
+			t.Errorf("api-version = %q", got)
+		}
+		if got := r.Header.Get("Idempotency-Key"); got != "key-1" {
+			t.Errorf("Idempotency-Key = %q", got)
+		}
+		if got := r.Header.Get("X-Source"); got != "test" {
+			t.Errorf("X-Source = %q", got)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body error: %v", err)
+		}
+		if body["input"] != "hello" || body["dimensions"] != float64(256) || body["model"] != "override" {
+			t.Errorf("body = %v", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list"}`))
+	})
+
+	_, err := c.CreateEmbeddings(context.Background(), EmbeddingsRequestBody{
+		Model: TextEmbeddingAda002,
+		Input: "hello",
+	},
+		WithBaseURL("https://proxy.example.com/openai/"),
+		WithQuery("api-version", "2023-05-15"),
+		WithIdempotencyKey("key-1"),
+		WithHeader("X-Source", "test"),
+		WithExtraField("dimensions", 256),
+		WithExtraField("model", "override"),
+	)
+	if err != nil {
+		t.Fatalf("create embeddings error: %v", err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	start := time.Now()
+	_, err := c.ListModels(context.Background(), WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("call took %s", elapsed)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if len(body) == 0 {
+			t.Error("retried request has no body")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"slow down","type":"requests"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list"}`))
+	})
+	var calls int
+	c.Use(func(next Handler) Handler {
+		return func(call *Call) error {
+			calls++
+			return next(call)
+		}
+	})
+	body := EmbeddingsRequestBody{Model: TextEmbeddingAda002, Input: "hello"}
+
+	if _, err := c.CreateEmbeddings(context.Background(), body); statusCodeOf(err) != http.StatusTooManyRequests {
+		t.Errorf("error without retry = %v", err)
+	}
+
+	attempts.Store(0)
+	c.Retry = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	if _, err := c.CreateEmbeddings(context.Background(), body); err != nil {
+		t.Errorf("error with retry = %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+
+	attempts.Store(0)
+	if _, err := c.CreateEmbeddings(context.Background(), body, WithRetry(RetryPolicy{MaxAttempts: 2})); err == nil {
+		t.Error("per-call retry policy not applied")
+	}
+	if calls != 3 {
+		t.Errorf("middleware saw %d calls, want 3", calls)
+	}
+}
+
+func TestRetry_malformedResponse(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":`))
+	})
+	c.Retry = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	body := EmbeddingsRequestBody{Model: TextEmbeddingAda002, Input: "hello"}
+	if _, err := c.CreateEmbeddings(context.Background(), body); err == nil {
+		t.Error("malformed response accepted")
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&Error{StatusCode: http.StatusTooManyRequests}, true},
+		{&RequestError{StatusCode: http.StatusBadGateway}, true},
+		{&Error{StatusCode: http.StatusBadRequest}, false},
+		{&url.Error{Op: "Post", URL: "https://api.openai.com", Err: io.ErrUnexpectedEOF}, true},
+		{&url.Error{Op: "Post", URL: "https://api.openai.com", Err: context.Canceled}, false},
+		{io.ErrUnexpectedEOF, false},
+		{&json.SyntaxError{}, false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		if d := p.backoff(attempt, nil); d < limit/2 || d > limit {
+			t.Errorf("backoff(%d) = %s, want between %s and %s", attempt, d, limit/2, limit)
+		}
+	}
+	res := &http.Response{Header: http.Header{"Retry-After": {"5"}}}
+	if d := p.backoff(1, res); d != time.Second {
+		t.Errorf("backoff with Retry-After = %s, want capped to 1s", d)
+	}
+}
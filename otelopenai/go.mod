@@ -0,0 +1,20 @@
+module github.com/im15/openai-api-go/otelopenai
+
+go 1.21
+
+require (
+	github.com/im15/openai-api-go v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+)
+
+replace github.com/im15/openai-api-go => ../
@@ -0,0 +1,380 @@
+// Package otelopenai instruments an openai.Client with OpenTelemetry.
+//
+// The middleware returned by Middleware emits one client span per call,
+// following the GenAI semantic conventions, and records operation duration,
+// time to first chunk for streams, and token usage histograms. Telemetry goes
+// to the global providers unless others are configured, so it is a no-op
+// until the application installs an OpenTelemetry SDK.
+package otelopenai
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+const instrumentationName = "github.com/im15/openai-api-go/otelopenai"
+
+// Attribute keys from the GenAI semantic conventions.
+const (
+	keySystem             = attribute.Key("gen_ai.system")
+	keyOperationName      = attribute.Key("gen_ai.operation.name")
+	keyRequestModel       = attribute.Key("gen_ai.request.model")
+	keyRequestMaxTokens   = attribute.Key("gen_ai.request.max_tokens")
+	keyRequestTemperature = attribute.Key("gen_ai.request.temperature")
+	keyRequestTopP        = attribute.Key("gen_ai.request.top_p")
+	keyResponseID         = attribute.Key("gen_ai.response.id")
+	keyResponseModel      = attribute.Key("gen_ai.response.model")
+	keyFinishReasons      = attribute.Key("gen_ai.response.finish_reasons")
+	keyInputTokens        = attribute.Key("gen_ai.usage.input_tokens")
+	keyOutputTokens       = attribute.Key("gen_ai.usage.output_tokens")
+	keyTokenType          = attribute.Key("gen_ai.token.type")
+	keyPrompt             = attribute.Key("gen_ai.prompt")
+	keyCompletion         = attribute.Key("gen_ai.completion")
+	keyErrorType          = attribute.Key("error.type")
+	keyServerAddress      = attribute.Key("server.address")
+	keyStatusCode         = attribute.Key("http.response.status_code")
+)
+
+// operationNames maps Client methods to gen_ai.operation.name values. Methods
+// that are not listed use their own name.
+var operationNames = map[string]string{
+	"CreateChatCompletion": "chat",
+	"CreateCompletions":    "text_completion",
+	"CreateEmbeddings":     "embeddings",
+	"CreateEdit":           "edit",
+	"CreateImage":          "image_generation",
+	"CreateImageEdit":      "image_edit",
+	"CreateImageVariation": "image_variation",
+	"CreateTranscription":  "transcription",
+	"CreateTranslation":    "translation",
+	"CreateModeration":     "moderation",
+}
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	captureContent bool
+}
+
+// Option configures the middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the provider spans are created with. The global
+// provider is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider sets the provider metrics are recorded with. The global
+// provider is used by default.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+// WithCaptureContent records chat and completion prompts and completions as
+// span events. It is off by default since they may contain sensitive data.
+func WithCaptureContent(capture bool) Option {
+	return func(c *config) {
+		c.captureContent = capture
+	}
+}
+
+type instruments struct {
+	duration       metric.Float64Histogram
+	timeToFirstMsg metric.Float64Histogram
+	tokenUsage     metric.Int64Histogram
+}
+
+// Middleware returns an openai.Middleware that traces and measures every
+// call made by the client it is installed on.
+func Middleware(opts ...Option) openai.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(instrumentationName)
+	meter := cfg.meterProvider.Meter(instrumentationName)
+
+	var inst instruments
+	var err error
+	if inst.duration, err = meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithDescription("Duration of OpenAI API calls."),
+		metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if inst.timeToFirstMsg, err = meter.Float64Histogram("gen_ai.client.operation.time_to_first_chunk",
+		metric.WithDescription("Time until the first chunk of a streamed response was received."),
+		metric.WithUnit("s")); err != nil {
+		otel.Handle(err)
+	}
+	if inst.tokenUsage, err = meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithDescription("Number of input and output tokens used."),
+		metric.WithUnit("{token}")); err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next openai.Handler) openai.Handler {
+		return func(call *openai.Call) error {
+			return handle(tracer, &inst, cfg.captureContent, next, call)
+		}
+	}
+}
+
+func handle(tracer trace.Tracer,
+	inst *instruments,
+	captureContent bool,
+	next openai.Handler,
+	call *openai.Call) error {
+	start := time.Now()
+	operation := operationName(call.Operation)
+	model := call.Model()
+
+	common := []attribute.KeyValue{
+		keySystem.String("openai"),
+		keyOperationName.String(operation),
+		keyServerAddress.String(call.Request.URL.Hostname()),
+	}
+	if model != "" {
+		common = append(common, keyRequestModel.String(model))
+	}
+
+	spanName := operation
+	if model != "" {
+		spanName += " " + model
+	}
+	ctx, span := tracer.Start(call.Context(), spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(common...),
+		trace.WithAttributes(requestAttributes(call.Body)...))
+	call.Request = call.Request.WithContext(ctx)
+	if captureContent {
+		if prompt := promptContent(call.Body); prompt != "" {
+			span.AddEvent("gen_ai.content.prompt", trace.WithAttributes(keyPrompt.String(prompt)))
+		}
+	}
+
+	err := next(call)
+
+	if call.Response != nil {
+		span.SetAttributes(keyStatusCode.Int(call.Response.StatusCode))
+	}
+	if err != nil {
+		errType := errorType(call, err)
+		common = append(common, keyErrorType.String(errType))
+		span.SetAttributes(keyErrorType.String(errType))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		inst.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(common...))
+		span.End()
+		return err
+	}
+
+	span.SetAttributes(responseAttributes(call.Result)...)
+	if model := responseModel(call.Result); model != "" {
+		common = append(common, keyResponseModel.String(model))
+	}
+
+	finish := func(reasons []string, completion string) {
+		if len(reasons) > 0 {
+			span.SetAttributes(keyFinishReasons.StringSlice(reasons))
+		}
+		if usage := call.Usage(); usage != nil && usage.TotalTokens > 0 {
+			span.SetAttributes(
+				keyInputTokens.Int(usage.PromptTokens),
+				keyOutputTokens.Int(usage.CompletionTokens))
+			inst.tokenUsage.Record(ctx, int64(usage.PromptTokens),
+				metric.WithAttributes(append(common, keyTokenType.String("input"))...))
+			if usage.CompletionTokens > 0 {
+				inst.tokenUsage.Record(ctx, int64(usage.CompletionTokens),
+					metric.WithAttributes(append(common, keyTokenType.String("output"))...))
+			}
+		}
+		if captureContent && completion != "" {
+			span.AddEvent("gen_ai.content.completion", trace.WithAttributes(keyCompletion.String(completion)))
+		}
+		inst.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(common...))
+		span.End()
+	}
+
+	if call.Stream() {
+		var (
+			first      = true
+			model      string
+			reasons    []string
+			completion strings.Builder
+		)
+		intercepted := call.InterceptStream(func(chunk *openai.ChatStreamChunk) {
+			if first {
+				first = false
+				inst.timeToFirstMsg.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(common...))
+			}
+			if model == "" {
+				model = chunk.Model
+			}
+			for _, choice := range chunk.Choices {
+				if choice.FinishReason != nil {
+					reasons = append(reasons, *choice.FinishReason)
+				}
+				if captureContent && choice.Delta != nil {
+					completion.WriteString(choice.Delta.Content)
+				}
+			}
+		}, func() {
+			if model != "" {
+				span.SetAttributes(keyResponseModel.String(model))
+				common = append(common, keyResponseModel.String(model))
+			}
+			finish(reasons, completion.String())
+		})
+		if intercepted {
+			return nil
+		}
+	}
+
+	finish(finishReasons(call.Result), completionContent(call.Result))
+	return nil
+}
+
+func operationName(operation string) string {
+	if name, ok := operationNames[operation]; ok {
+		return name
+	}
+	return operation
+}
+
+// errorType returns the status code for API errors and the Go type of the
+// error otherwise.
+func errorType(call *openai.Call, err error) string {
+	if call.Response != nil && call.Response.StatusCode >= http.StatusBadRequest {
+		return strconv.Itoa(call.Response.StatusCode)
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+func requestAttributes(body any) []attribute.KeyValue {
+	var (
+		maxTokens         int
+		temperature, topP float32
+	)
+	switch b := body.(type) {
+	case openai.ChatRequestBody:
+		maxTokens, temperature, topP = b.MaxTokens, b.Temperature, b.TopP
+	case openai.CompletionRequestBody:
+		maxTokens, temperature, topP = b.MaxTokens, b.Temperature, b.TopP
+	case openai.EditRequestBody:
+		temperature, topP = b.Temperature, b.TopP
+	}
+	var attrs []attribute.KeyValue
+	if maxTokens > 0 {
+		attrs = append(attrs, keyRequestMaxTokens.Int(maxTokens))
+	}
+	if temperature != 0 {
+		attrs = append(attrs, keyRequestTemperature.Float64(float64(temperature)))
+	}
+	if topP != 0 {
+		attrs = append(attrs, keyRequestTopP.Float64(float64(topP)))
+	}
+	return attrs
+}
+
+func responseModel(result any) string {
+	switch r := result.(type) {
+	case *openai.ChatResponseBody:
+		return r.Model
+	case *openai.CompletionResponseBody:
+		return r.Model
+	case *openai.EmbeddingsResponseBody:
+		return r.Model
+	case *openai.ModerationResponseBody:
+		return r.Model
+	}
+	return ""
+}
+
+func responseAttributes(result any) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	var id string
+	switch r := result.(type) {
+	case *openai.ChatResponseBody:
+		id = r.ID
+	case *openai.CompletionResponseBody:
+		id = r.ID
+	case *openai.ModerationResponseBody:
+		id = r.ID
+	}
+	if id != "" {
+		attrs = append(attrs, keyResponseID.String(id))
+	}
+	if model := responseModel(result); model != "" {
+		attrs = append(attrs, keyResponseModel.String(model))
+	}
+	return attrs
+}
+
+func finishReasons(result any) []string {
+	var reasons []string
+	switch r := result.(type) {
+	case *openai.ChatResponseBody:
+		for _, choice := range r.Choices {
+			if choice.FinishReason != nil {
+				reasons = append(reasons, *choice.FinishReason)
+			}
+		}
+	case *openai.CompletionResponseBody:
+		for _, choice := range r.Choices {
+			reasons = append(reasons, choice.FinishReason)
+		}
+	}
+	return reasons
+}
+
+func promptContent(body any) string {
+	switch b := body.(type) {
+	case openai.ChatRequestBody:
+		data, _ := json.Marshal(b.Messages)
+		return string(data)
+	case openai.CompletionRequestBody:
+		return b.Prompt
+	case openai.EditRequestBody:
+		return b.Input
+	}
+	return ""
+}
+
+func completionContent(result any) string {
+	switch r := result.(type) {
+	case *openai.ChatResponseBody:
+		if len(r.Choices) > 0 && r.Choices[0].Message != nil {
+			return r.Choices[0].Message.Content
+		}
+	case *openai.CompletionResponseBody:
+		if len(r.Choices) > 0 {
+			return r.Choices[0].Text
+		}
+	case *openai.EditResponseBody:
+		if len(r.Choices) > 0 {
+			return r.Choices[0].Text
+		}
+	}
+	return ""
+}
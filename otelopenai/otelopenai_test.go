@@ -0,0 +1,192 @@
+package otelopenai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) (
+	*openai.Client, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	opts = append(opts,
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+
+	c := openai.NewClient("test-token")
+	c.HTTPClient = &http.Client{Transport: rewriteTransport{target: target}}
+	c.Use(Middleware(opts...))
+	return c, recorder, reader
+}
+
+func attrs(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func metricNames(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect error: %v", err)
+	}
+	names := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names[m.Name] = m.Data
+		}
+	}
+	return names
+}
+
+func TestMiddleware_ChatCompletion(t *testing.T) {
+	c, recorder, reader := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-3.5-turbo-0125",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":9,"completion_tokens":3,"total_tokens":12}}`)
+	}, WithCaptureContent(true))
+
+	_, err := c.CreateChatCompletion(context.Background(), openai.ChatRequestBody{
+		Model:       openai.GPT35Turbo,
+		Temperature: 0.5,
+		Messages:    []*openai.ChatMessage{{Role: openai.RoleUser, Content: "Hello!"}},
+	})
+	if err != nil {
+		t.Fatalf("create chat completion error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "chat gpt-3.5-turbo" {
+		t.Errorf("span name = %q", span.Name())
+	}
+	got := attrs(span.Attributes())
+	want := map[attribute.Key]attribute.Value{
+		keyOperationName:      attribute.StringValue("chat"),
+		keyRequestModel:       attribute.StringValue(openai.GPT35Turbo),
+		keyRequestTemperature: attribute.Float64Value(0.5),
+		keyResponseID:         attribute.StringValue("chatcmpl-1"),
+		keyResponseModel:      attribute.StringValue("gpt-3.5-turbo-0125"),
+		keyInputTokens:        attribute.IntValue(9),
+		keyOutputTokens:       attribute.IntValue(3),
+		keyFinishReasons:      attribute.StringSliceValue([]string{"stop"}),
+		keyStatusCode:         attribute.IntValue(http.StatusOK),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("attribute %s = %v, want %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+	if events := span.Events(); len(events) != 2 {
+		t.Errorf("got %d events, want prompt and completion", len(events))
+	}
+
+	names := metricNames(t, reader)
+	for _, name := range []string{"gen_ai.client.operation.duration", "gen_ai.client.token.usage"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("metric %s not recorded", name)
+		}
+	}
+}
+
+func TestMiddleware_Stream(t *testing.T) {
+	c, recorder, reader := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"id":"1","model":"gpt-3.5-turbo-0125","choices":[{"index":0,"delta":{"role":"assistant"}}]}`,
+			`{"id":"1","choices":[{"index":0,"delta":{"content":"Hi"}}]}`,
+			`{"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`[DONE]`,
+		} {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	})
+
+	body, err := c.CreateChatCompletion(context.Background(), openai.ChatRequestBody{
+		Model:    openai.GPT35Turbo,
+		Stream:   true,
+		Messages: []*openai.ChatMessage{{Role: openai.RoleUser, Content: "Hello!"}},
+	})
+	if err != nil {
+		t.Fatalf("create chat completion error: %v", err)
+	}
+	var n int
+	for range body.StreamChan {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("got %d chunks, want 3", n)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1 after the stream ended", len(spans))
+	}
+	got := attrs(spans[0].Attributes())
+	if got[keyFinishReasons] != attribute.StringSliceValue([]string{"stop"}) {
+		t.Errorf("finish reasons = %v", got[keyFinishReasons].Emit())
+	}
+	if got[keyResponseModel] != attribute.StringValue("gpt-3.5-turbo-0125") {
+		t.Errorf("response model = %v", got[keyResponseModel].Emit())
+	}
+	if _, ok := metricNames(t, reader)["gen_ai.client.operation.time_to_first_chunk"]; !ok {
+		t.Error("time to first chunk not recorded")
+	}
+}
+
+func TestMiddleware_Error(t *testing.T) {
+	c, recorder, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = fmt.Fprint(w, `{"error":{"message":"slow down","type":"requests"}}`)
+	})
+
+	if _, err := c.ListModels(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("status = %v, want error", spans[0].Status())
+	}
+	if got := attrs(spans[0].Attributes())[keyErrorType]; got.AsString() != "429" {
+		t.Errorf("error.type = %q, want 429", got.AsString())
+	}
+}
@@ -0,0 +1,158 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Page is a page of a cursor-paginated list.
+type Page[T any] struct {
+	Object  string `json:"object"`
+	Data    []T    `json:"data"`
+	FirstID string `json:"first_id,omitempty"`
+	LastID  string `json:"last_id,omitempty"`
+	// HasMore reports whether another page follows. Endpoints that do not
+	// paginate leave it false.
+	HasMore bool `json:"has_more"`
+
+	ResponseMeta
+}
+
+// ListParams selects the items of a list.
+type ListParams struct {
+	// Limit is the number of items per page, the endpoint default when zero.
+	Limit int
+	// After is the ID of the item the list starts after.
+	After string
+	// Order sorts the items by creation time, "asc" or "desc".
+	Order string
+}
+
+func (p ListParams) options() []RequestOption {
+	var opts []RequestOption
+	if p.Limit > 0 {
+		opts = append(opts, WithQuery("limit", strconv.Itoa(p.Limit)))
+	}
+	if p.After != "" {
+		opts = append(opts, WithQuery("after", p.After))
+	}
+	if p.Order != "" {
+		opts = append(opts, WithQuery("order", p.Order))
+	}
+	return opts
+}
+
+// PageFetcher fetches the page of a list selected by params.
+type PageFetcher[T any] func(ctx context.Context, params ListParams) (*Page[T], error)
+
+// Paginator walks a cursor-paginated list item by item, fetching pages as
+// they are needed:
+//
+//	p := c.PaginateFiles(openai.ListParams{Limit: 100})
+//	for p.Next(ctx) {
+//		file := p.Current()
+//		...
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+//
+// A Paginator is not safe for concurrent use.
+type Paginator[T any] struct {
+	fetch  PageFetcher[T]
+	id     func(T) string
+	params ListParams
+
+	page    *Page[T]
+	index   int
+	fetched bool
+	err     error
+}
+
+// NewPaginator returns a Paginator over the list fetched by fetch, starting
+// at params. id returns the ID of an item, which is the cursor of the next
+// page when the API does not return last_id.
+func NewPaginator[T any](params ListParams, id func(T) string, fetch PageFetcher[T]) *Paginator[T] {
+	return &Paginator[T]{fetch: fetch, id: id, params: params}
+}
+
+// Next advances to the next item, fetching the next page when the current
+// one is exhausted. It reports false at the end of the list or on error.
+func (p *Paginator[T]) Next(ctx context.Context) bool {
+	if p.err != nil {
+		return false
+	}
+	if p.page != nil && p.index+1 < len(p.page.Data) {
+		p.index++
+		return true
+	}
+	for {
+		if p.fetched && (p.page == nil || !p.page.HasMore) {
+			return false
+		}
+		if p.page != nil {
+			cursor := p.page.LastID
+			if cursor == "" && len(p.page.Data) > 0 {
+				cursor = p.id(p.page.Data[len(p.page.Data)-1])
+			}
+			if cursor == "" || cursor == p.params.After {
+				// Stop rather than fetch the same page again.
+				return false
+			}
+			p.params.After = cursor
+		}
+		page, err := p.fetch(ctx, p.params)
+		p.fetched = true
+		if err != nil {
+			p.err = err
+			return false
+		}
+		p.page, p.index = page, 0
+		if len(page.Data) > 0 {
+			return true
+		}
+	}
+}
+
+// Current returns the item Next advanced to.
+func (p *Paginator[T]) Current() T {
+	return p.page.Data[p.index]
+}
+
+// Page returns the page of the current item, nil before the first call to
+// Next.
+func (p *Paginator[T]) Page() *Page[T] {
+	return p.page
+}
+
+// Err returns the error that stopped Next, if any.
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// All collects the remaining items of the list.
+func (p *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	var items []T
+	for p.Next(ctx) {
+		items = append(items, p.Current())
+	}
+	return items, p.Err()
+}
+
+// paginate returns a Paginator over a list endpoint of the API.
+func paginate[T any](c *Client,
+	operation string,
+	url string,
+	params ListParams,
+	id func(T) string,
+	opts []RequestOption) *Paginator[T] {
+	return NewPaginator(params, id, func(ctx context.Context, params ListParams) (*Page[T], error) {
+		page := &Page[T]{}
+		err := c.do(ctx, operation, http.MethodGet, url, nil, page, append(params.options(), opts...)...)
+		if err != nil {
+			return nil, err
+		}
+		return page, nil
+	})
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// fakeList serves items "1" to "n" in pages, recording the cursors asked for.
+type fakeList struct {
+	n       int
+	cursors []string
+	// lastID controls whether pages carry last_id.
+	lastID bool
+}
+
+func (l *fakeList) fetch(_ context.Context, params ListParams) (*Page[string], error) {
+	l.cursors = append(l.cursors, params.After)
+	start := 0
+	if params.After != "" {
+		start, _ = strconv.Atoi(params.After)
+	}
+	page := &Page[string]{Object: "list"}
+	for i := start + 1; i <= l.n && len(page.Data) < params.Limit; i++ {
+		page.Data = append(page.Data, strconv.Itoa(i))
+	}
+	if len(page.Data) > 0 {
+		page.HasMore = page.Data[len(page.Data)-1] != strconv.Itoa(l.n)
+		if l.lastID {
+			page.LastID = page.Data[len(page.Data)-1]
+		}
+	}
+	return page, nil
+}
+
+func identity(s string) string { return s }
+
+func TestPaginator(t *testing.T) {
+	for _, lastID := range []bool{true, false} {
+		l := &fakeList{n: 7, lastID: lastID}
+		p := NewPaginator(ListParams{Limit: 3}, identity, l.fetch)
+		items, err := p.All(context.Background())
+		if err != nil {
+			t.Fatalf("all error: %v", err)
+		}
+		if want := []string{"1", "2", "3", "4", "5", "6", "7"}; !reflect.DeepEqual(items, want) {
+			t.Errorf("items = %v, want %v", items, want)
+		}
+		if want := []string{"", "3", "6"}; !reflect.DeepEqual(l.cursors, want) {
+			t.Errorf("cursors = %v, want %v", l.cursors, want)
+		}
+		if p.Next(context.Background()) {
+			t.Error("Next after the end reported true")
+		}
+	}
+}
+
+func TestPaginator_lazy(t *testing.T) {
+	l := &fakeList{n: 10, lastID: true}
+	p := NewPaginator(ListParams{Limit: 2, After: "4"}, identity, l.fetch)
+	for i := 0; i < 3; i++ {
+		if !p.Next(context.Background()) {
+			t.Fatalf("Next %d reported false: %v", i, p.Err())
+		}
+	}
+	if got := p.Current(); got != "7" {
+		t.Errorf("Current = %q, want 7", got)
+	}
+	if len(l.cursors) != 2 {
+		t.Errorf("fetched %d pages, want 2", len(l.cursors))
+	}
+}
+
+func TestPaginator_error(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	p := NewPaginator(ListParams{}, identity, func(context.Context, ListParams) (*Page[string], error) {
+		return nil, errFetch
+	})
+	if _, err := p.All(context.Background()); !errors.Is(err, errFetch) {
+		t.Errorf("error = %v, want %v", err, errFetch)
+	}
+}
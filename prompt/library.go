@@ -0,0 +1,151 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// ErrNotFound is returned for a template that is not in a Library.
+var ErrNotFound = errors.New("prompt: template not found")
+
+// Library holds the versions of named templates and the partials they share.
+// A Library is safe for concurrent use.
+type Library struct {
+	mu        sync.RWMutex
+	partials  *template.Template
+	templates map[string][]*Template
+}
+
+// NewLibrary returns an empty Library.
+func NewLibrary() *Library {
+	return &Library{
+		partials:  template.New("").Funcs(placeholderFuncs),
+		templates: make(map[string][]*Template),
+	}
+}
+
+// ParseFS returns a Library of the .tmpl files of a directory of fsys. A
+// file "name.tmpl" is version 0 of a template, "name.v2.tmpl" version 2.
+// Files whose name starts with '_' are partials, e.g. "_persona.tmpl" is
+// included with {{template "persona" .}}.
+func ParseFS(fsys fs.FS, dir string) (*Library, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	l := NewLibrary()
+	// Partials come first, so that every template sees them.
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.HasPrefix(entries[i].Name(), "_") && !strings.HasPrefix(entries[j].Name(), "_")
+	})
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), ".tmpl")
+		if !ok || e.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if partial, ok := strings.CutPrefix(base, "_"); ok {
+			err = l.AddPartial(partial, string(data))
+		} else {
+			name, version := splitVersion(base)
+			_, err = l.Add(name, version, string(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+	}
+	return l, nil
+}
+
+// LoadDir returns the Library of the .tmpl files of a directory, as ParseFS.
+func LoadDir(dir string) (*Library, error) {
+	return ParseFS(os.DirFS(dir), ".")
+}
+
+// splitVersion splits "name.v2" into name and version.
+func splitVersion(base string) (string, int) {
+	if i := strings.LastIndex(base, ".v"); i > 0 {
+		if v, err := strconv.Atoi(base[i+2:]); err == nil && v >= 0 {
+			return base[:i], v
+		}
+	}
+	return base, 0
+}
+
+// AddPartial adds a partial, available to the templates added after it with
+// {{template "name" .}}.
+func (l *Library) AddPartial(name, text string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.partials.New(name).Option("missingkey=error").Parse(text); err != nil {
+		return fmt.Errorf("prompt: %w", err)
+	}
+	return nil
+}
+
+// Add parses a version of a template and adds it to the library.
+func (l *Library) Add(name string, version int, text string) (*Template, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, err := parse(name, text, l.partials)
+	if err != nil {
+		return nil, err
+	}
+	t.Version = version
+	versions := l.templates[name]
+	for _, v := range versions {
+		if v.Version == version {
+			return nil, fmt.Errorf("prompt: %s version %d added twice", name, version)
+		}
+	}
+	versions = append(versions, t)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	l.templates[name] = versions
+	return t, nil
+}
+
+// Get returns the latest version of a template.
+func (l *Library) Get(name string) (*Template, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	versions := l.templates[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Version returns a version of a template.
+func (l *Library) Version(name string, version int) (*Template, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, t := range l.templates[name] {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s version %d", ErrNotFound, name, version)
+}
+
+// Names returns the names of the templates, sorted.
+func (l *Library) Names() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	names := make([]string, 0, len(l.templates))
+	for name := range l.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
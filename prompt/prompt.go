@@ -0,0 +1,305 @@
+// Package prompt renders chat prompts from text/template templates into
+// []*openai.ChatMessage.
+//
+// The role actions system, user and assistant start the messages:
+//
+//	---
+//	description: Answers questions about a product
+//	var: product string required
+//	var: tone string = "friendly"
+//	var: examples examples
+//	---
+//	{{system}}You are a {{.tone}} support agent for {{.product}}.
+//	{{examples .examples}}
+//	{{user}}{{.question}}
+//
+// The front matter between the "---" lines, optional, describes the template
+// and declares its variables, whose values are checked against their type
+// before rendering. The text of every message is trimmed of surrounding
+// whitespace. Templates are executed with missingkey=error, so referring to a
+// variable that was not given is an error.
+package prompt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	openai "github.com/im15/openai-api-go"
+)
+
+var (
+	// ErrMissingVariable is returned when a required variable is not given.
+	ErrMissingVariable = errors.New("prompt: missing variable")
+	// ErrInvalidVariable is returned when a variable has the wrong type.
+	ErrInvalidVariable = errors.New("prompt: invalid variable")
+)
+
+// Type is the type of a variable.
+type Type string
+
+const (
+	String  Type = "string"
+	Int     Type = "int"
+	Float   Type = "float"
+	Bool    Type = "bool"
+	Strings Type = "[]string"
+	// Examples is a []Example.
+	Examples Type = "examples"
+	// Any accepts any value.
+	Any Type = "any"
+)
+
+// Variable declares a variable of a template.
+type Variable struct {
+	Name     string
+	Type     Type
+	Required bool
+	// Default is the value of an optional variable that is not given.
+	Default any
+}
+
+// check reports whether v is a value of the type of the variable.
+func (v Variable) check(value any) error {
+	ok := true
+	switch v.Type {
+	case String:
+		_, ok = value.(string)
+	case Int:
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			ok = false
+		}
+	case Float:
+		switch reflect.ValueOf(value).Kind() {
+		case reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			ok = false
+		}
+	case Bool:
+		_, ok = value.(bool)
+	case Strings:
+		_, ok = value.([]string)
+	case Examples:
+		_, ok = value.([]Example)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s is %T, not %s", ErrInvalidVariable, v.Name, value, v.Type)
+	}
+	return nil
+}
+
+// Example is a few-shot example: a user message and the reply expected from
+// the assistant.
+type Example struct {
+	Input  string `json:"input"`
+	Output string `json:"output"`
+}
+
+// Vars are the values of the variables of a template.
+type Vars map[string]any
+
+// Template is a parsed prompt template.
+type Template struct {
+	Name string
+	// Version is read from the file name, 0 when it has none.
+	Version     int
+	Description string
+	Variables   []Variable
+
+	tmpl *template.Template
+}
+
+// placeholderFuncs are replaced by the role markers of every rendering.
+var placeholderFuncs = template.FuncMap{
+	"system":    func() string { return "" },
+	"user":      func() string { return "" },
+	"assistant": func() string { return "" },
+	"examples":  func([]Example) string { return "" },
+}
+
+// Parse parses a template with optional front matter.
+func Parse(name, text string) (*Template, error) {
+	return parse(name, text, nil)
+}
+
+// parse parses a template, associating it with partials.
+func parse(name, text string, partials *template.Template) (*Template, error) {
+	t := &Template{Name: name}
+	body, err := t.parseFrontMatter(text)
+	if err != nil {
+		return nil, fmt.Errorf("prompt: %s: %w", name, err)
+	}
+	if partials != nil {
+		if t.tmpl, err = partials.Clone(); err != nil {
+			return nil, err
+		}
+		t.tmpl = t.tmpl.New(name)
+	} else {
+		t.tmpl = template.New(name).Funcs(placeholderFuncs)
+	}
+	if _, err = t.tmpl.Option("missingkey=error").Parse(body); err != nil {
+		return nil, fmt.Errorf("prompt: %w", err)
+	}
+	return t, nil
+}
+
+// parseFrontMatter reads the description and variables of the front matter
+// and returns the text after it.
+func (t *Template) parseFrontMatter(text string) (string, error) {
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return text, nil
+	}
+	front, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return "", errors.New("unterminated front matter")
+	}
+	for i, line := range strings.Split(front, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return "", fmt.Errorf("front matter line %d: missing ':'", i+1)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "description":
+			t.Description = value
+		case "var":
+			v, err := parseVariable(value)
+			if err != nil {
+				return "", fmt.Errorf("front matter line %d: %w", i+1, err)
+			}
+			t.Variables = append(t.Variables, v)
+		default:
+			return "", fmt.Errorf("front matter line %d: unknown key %q", i+1, key)
+		}
+	}
+	return body, nil
+}
+
+// parseVariable parses "name type [required] [= default]".
+func parseVariable(s string) (Variable, error) {
+	decl, def, hasDefault := strings.Cut(s, "=")
+	fields := strings.Fields(decl)
+	if len(fields) < 2 || len(fields) > 3 || len(fields) == 3 && fields[2] != "required" {
+		return Variable{}, fmt.Errorf("invalid variable %q", s)
+	}
+	v := Variable{Name: fields[0], Type: Type(fields[1]), Required: len(fields) == 3}
+	if !hasDefault {
+		return v, nil
+	}
+	def = strings.TrimSpace(def)
+	var err error
+	switch v.Type {
+	case String:
+		v.Default = def
+		if strings.HasPrefix(def, `"`) {
+			v.Default, err = strconv.Unquote(def)
+		}
+	case Int:
+		v.Default, err = strconv.Atoi(def)
+	case Float:
+		v.Default, err = strconv.ParseFloat(def, 64)
+	case Bool:
+		v.Default, err = strconv.ParseBool(def)
+	default:
+		err = fmt.Errorf("type %s has no default", v.Type)
+	}
+	if err != nil {
+		return Variable{}, fmt.Errorf("variable %s: %w", v.Name, err)
+	}
+	return v, nil
+}
+
+// data checks vars against the declared variables and adds the defaults.
+func (t *Template) data(vars Vars) (map[string]any, error) {
+	data := make(map[string]any, len(vars)+len(t.Variables))
+	for k, v := range vars {
+		data[k] = v
+	}
+	for _, v := range t.Variables {
+		value, ok := vars[v.Name]
+		switch {
+		case ok:
+			if err := v.check(value); err != nil {
+				return nil, err
+			}
+		case v.Required:
+			return nil, fmt.Errorf("%w: %s", ErrMissingVariable, v.Name)
+		default:
+			data[v.Name] = v.Default
+		}
+	}
+	return data, nil
+}
+
+// Render renders the messages of the template.
+func (t *Template) Render(vars Vars) ([]*openai.ChatMessage, error) {
+	data, err := t.data(vars)
+	if err != nil {
+		return nil, err
+	}
+	// Role markers carry a random nonce so that variables cannot fake them.
+	var nonce [8]byte
+	_, _ = rand.Read(nonce[:])
+	marker := "\x00" + hex.EncodeToString(nonce[:]) + ":"
+	role := func(role string) func() string {
+		return func() string { return marker + role + "\x00" }
+	}
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"system":    role(openai.RoleSystem),
+		"user":      role(openai.RoleUser),
+		"assistant": role(openai.RoleAssistant),
+		"examples": func(examples []Example) string {
+			var b strings.Builder
+			for _, e := range examples {
+				b.WriteString(role(openai.RoleUser)() + e.Input + role(openai.RoleAssistant)() + e.Output)
+			}
+			return b.String()
+		},
+	})
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("prompt: %w", err)
+	}
+
+	sections := strings.Split(buf.String(), marker)
+	if strings.TrimSpace(sections[0]) != "" {
+		return nil, fmt.Errorf("prompt: %s: text before the first role", t.Name)
+	}
+	messages := make([]*openai.ChatMessage, 0, len(sections)-1)
+	for _, s := range sections[1:] {
+		role, content, _ := strings.Cut(s, "\x00")
+		messages = append(messages, &openai.ChatMessage{Role: role, Content: strings.TrimSpace(content)})
+	}
+	return messages, nil
+}
+
+// Tokens renders the template and returns the prompt tokens of its messages
+// for a model, as counted by openai.CountPromptTokens, which needs the rank
+// files of package tokenizer/ranks.
+func (t *Template) Tokens(model string, vars Vars) (int, error) {
+	messages, err := t.Render(vars)
+	if err != nil {
+		return 0, err
+	}
+	return openai.CountPromptTokens(openai.ChatRequestBody{Model: model, Messages: messages})
+}
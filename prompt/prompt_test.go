@@ -0,0 +1,160 @@
+package prompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	openai "github.com/im15/openai-api-go"
+	_ "github.com/im15/openai-api-go/tokenizer/ranks"
+)
+
+const support = `---
+description: Answers questions about a product
+var: product string required
+var: tone string = "friendly"
+var: turns int = 3
+var: examples examples
+---
+{{system}}You are a {{.tone}} support agent for {{.product}}.
+{{examples .examples}}
+{{user}}{{.question}}
+`
+
+// render summarizes messages as "role: content" lines.
+func render(messages []*openai.ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role + ": " + m.Content + "\n")
+	}
+	return b.String()
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := Parse("support", support)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Description != "Answers questions about a product" || len(tmpl.Variables) != 4 ||
+		tmpl.Variables[1] != (Variable{Name: "tone", Type: String, Default: "friendly"}) ||
+		tmpl.Variables[2] != (Variable{Name: "turns", Type: Int, Default: 3}) {
+		t.Errorf("Parse() = %+v", tmpl)
+	}
+
+	messages, err := tmpl.Render(Vars{
+		"product":  "Acme",
+		"examples": []Example{{Input: "Hi", Output: "Hello!"}},
+		// A variable cannot start a message of its own.
+		"question": "\x00user\x00How do I reset it?",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "system: You are a friendly support agent for Acme.\n" +
+		"user: Hi\n" +
+		"assistant: Hello!\n" +
+		"user: \x00user\x00How do I reset it?\n"
+	if got := render(messages); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct {
+		vars Vars
+		want error
+	}{
+		{Vars{"question": "?"}, ErrMissingVariable},
+		{Vars{"product": 1, "question": "?"}, ErrInvalidVariable},
+		{Vars{"product": "Acme", "turns": "3", "question": "?"}, ErrInvalidVariable},
+		{Vars{"product": "Acme"}, nil},
+	} {
+		if _, err = tmpl.Render(tt.vars); tt.want != nil && !errors.Is(err, tt.want) || tt.want == nil && err == nil {
+			t.Errorf("Render(%v) = %v, want %v", tt.vars, err, tt.want)
+		}
+	}
+
+	if _, err = Parse("text", "Hello {{user}}there"); err != nil {
+		t.Fatal(err)
+	}
+	for name, text := range map[string]string{
+		"unterminated front matter": "---\nvar: a string\n",
+		"unknown key":               "---\nmodel: gpt-4\n---\n",
+		"invalid default":           "---\nvar: n int = many\n---\n",
+		"invalid template":          "{{user}}{{.a",
+	} {
+		if _, err = Parse(name, text); err == nil {
+			t.Errorf("Parse(%s) succeeded", name)
+		}
+	}
+	if tmpl, _ = Parse("text", "Hello {{user}}there"); tmpl != nil {
+		if _, err = tmpl.Render(nil); err == nil {
+			t.Error("Render() with text before the first role succeeded")
+		}
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	fsys := fstest.MapFS{
+		"prompts/_persona.tmpl":     {Data: []byte(`You are {{.name}}.`)},
+		"prompts/greet.tmpl":        {Data: []byte(`{{system}}{{template "persona" .}}{{user}}Hi`)},
+		"prompts/greet.v2.tmpl":     {Data: []byte(`{{system}}{{template "persona" .}} Be brief.{{user}}Hi`)},
+		"prompts/summarize.v1.tmpl": {Data: []byte(`{{system}}Summarize.{{user}}{{.text}}`)},
+		"prompts/README.md":         {Data: []byte(`not a template`)},
+	}
+	l, err := ParseFS(fsys, "prompts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := strings.Join(l.Names(), ","); names != "greet,summarize" {
+		t.Errorf("Names() = %s", names)
+	}
+	latest, err := l.Get("greet")
+	if err != nil || latest.Version != 2 {
+		t.Fatalf("Get(greet) = %+v, %v", latest, err)
+	}
+	messages, err := latest.Render(Vars{"name": "Otto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := render(messages), "system: You are Otto. Be brief.\nuser: Hi\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if first, err := l.Version("greet", 0); err != nil || first.Name != "greet" {
+		t.Errorf("Version(greet, 0) = %+v, %v", first, err)
+	}
+	if _, err = l.Version("greet", 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Version(greet, 3) = %v, want ErrNotFound", err)
+	}
+	if _, err = l.Add("greet", 2, "{{user}}Hi"); err == nil {
+		t.Error("Add() of an existing version succeeded")
+	}
+
+	tokens, err := latest.Tokens(openai.GPT4, Vars{"name": "Otto"})
+	want, _ := openai.CountPromptTokens(openai.ChatRequestBody{Model: openai.GPT4, Messages: messages})
+	if err != nil || tokens != want || tokens == 0 {
+		t.Errorf("Tokens() = %d, %v, want %d", tokens, err, want)
+	}
+}
+
+func TestVariable_check(t *testing.T) {
+	for _, tt := range []struct {
+		typ   Type
+		value any
+		ok    bool
+	}{
+		{Int, uint8(1), true},
+		{Int, 1.5, false},
+		{Float, 1.5, true},
+		{Float, float32(1.5), true},
+		{Float, 1, true},
+		{Float, int32(1), true},
+		{Float, uint64(1), true},
+		{Float, "1", false},
+		{Strings, []string{"a"}, true},
+	} {
+		err := Variable{Name: "v", Type: tt.typ}.check(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("check(%s, %T) = %v", tt.typ, tt.value, err)
+		}
+	}
+}
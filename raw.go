@@ -0,0 +1,166 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RawJSON returns the response body as received from the API, or nil when
+// the response was not decoded from JSON.
+func (m ResponseMeta) RawJSON() json.RawMessage {
+	return m.raw
+}
+
+// UnknownFields returns the fields of the response that its type does not
+// model, keyed by name, or nil when there are none. They make fields added
+// to the API usable before the library catches up.
+func (m ResponseMeta) UnknownFields() map[string]json.RawMessage {
+	return m.unknown
+}
+
+// decodeResult unmarshals a JSON response into v and records the raw JSON
+// and unknown fields in the ResponseMeta of v and of the values nested in it.
+func decodeResult(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	attachRaw(reflect.ValueOf(v), data)
+	return nil
+}
+
+// encodeResult returns the JSON of a decoded result, the raw JSON it was
+// decoded from when available so that unknown fields are kept.
+func encodeResult(v any) ([]byte, error) {
+	if meta := responseMetaOf(v); meta != nil && meta.raw != nil {
+		return meta.raw, nil
+	}
+	return json.Marshal(v)
+}
+
+var responseMetaType = reflect.TypeOf(ResponseMeta{})
+
+func attachRaw(v reflect.Value, raw json.RawMessage) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if !hasResponseMeta(v.Type()) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		var elems []json.RawMessage
+		if json.Unmarshal(raw, &elems) != nil || len(elems) != v.Len() {
+			return
+		}
+		for i, elem := range elems {
+			attachRaw(v.Index(i), elem)
+		}
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) != nil {
+			return
+		}
+		known := jsonFields(v.Type())
+		if v.CanAddr() {
+			if meta := responseMetaOf(v.Addr().Interface()); meta != nil {
+				meta.raw = append(json.RawMessage(nil), bytes.TrimSpace(raw)...)
+				meta.unknown = nil
+				for name, value := range fields {
+					if _, ok := known[name]; !ok {
+						if meta.unknown == nil {
+							meta.unknown = make(map[string]json.RawMessage)
+						}
+						meta.unknown[name] = value
+					}
+				}
+			}
+		}
+		for name, index := range known {
+			if value, ok := fields[name]; ok {
+				attachRaw(v.FieldByIndex(index), value)
+			}
+		}
+	}
+}
+
+var (
+	metaTypes  sync.Map // reflect.Type -> bool
+	fieldNames sync.Map // reflect.Type -> map[string][]int
+)
+
+// hasResponseMeta reports whether values of t contain a ResponseMeta.
+func hasResponseMeta(t reflect.Type) bool {
+	if has, ok := metaTypes.Load(t); ok {
+		return has.(bool)
+	}
+	// Guards against recursive types while the answer is computed.
+	metaTypes.Store(t, false)
+	var has bool
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		has = hasResponseMeta(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !has; i++ {
+			f := t.Field(i)
+			has = f.Type == responseMetaType || (f.IsExported() && hasResponseMeta(f.Type))
+		}
+	}
+	metaTypes.Store(t, has)
+	return has
+}
+
+// jsonFields returns the index of every field of struct type t by JSON name,
+// including the fields promoted from embedded structs.
+func jsonFields(t reflect.Type) map[string][]int {
+	if fields, ok := fieldNames.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && f.Type.Kind() == reflect.Struct {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Index
+	}
+	fieldNames.Store(t, fields)
+	return fields
+}
+
+// marshalWithExtraFields marshals v, a request body converted to a type
+// without a MarshalJSON method, and merges extraFields into the result.
+func marshalWithExtraFields(v any, extraFields map[string]any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extraFields) == 0 {
+		return data, err
+	}
+	return mergeFields(data, extraFields)
+}
+
+// mergeFields sets fields in a JSON object, replacing those of the same name.
+func mergeFields(data []byte, fields map[string]any) ([]byte, error) {
+	// Numbers are kept as written, so that integers above 2^53 keep their
+	// precision.
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	merged := make(map[string]any)
+	if err := d.Decode(&merged); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return json.Marshal(merged)
+}
@@ -0,0 +1,155 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestResponseMeta_RawJSON(t *testing.T) {
+	const body = `{"object":"list","data":[` +
+		`{"id":"gpt-4","object":"model","owned_by":"openai","context_window":8192},` +
+		`{"id":"gpt-3.5-turbo","object":"model","owned_by":"openai"}` +
+		`],"has_more":false}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+	res, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("list models error: %v", err)
+	}
+	if string(res.RawJSON()) != body {
+		t.Errorf("RawJSON = %s", res.RawJSON())
+	}
+	if want := map[string]json.RawMessage{"has_more": json.RawMessage("false")}; !reflect.DeepEqual(res.UnknownFields(), want) {
+		t.Errorf("UnknownFields = %v, want %v", res.UnknownFields(), want)
+	}
+	model := res.Data[0]
+	if model.OwnerBy != "openai" {
+		t.Errorf("OwnerBy = %q", model.OwnerBy)
+	}
+	if got := string(model.UnknownFields()["context_window"]); got != "8192" {
+		t.Errorf("context_window = %q", got)
+	}
+	if res.Data[1].UnknownFields() != nil {
+		t.Errorf("UnknownFields of second model = %v", res.Data[1].UnknownFields())
+	}
+}
+
+func TestResponseMeta_stream(t *testing.T) {
+	chunks := []string{
+		`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hi"}}],"obfuscation":"a1"}`,
+		`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	res, err := c.CreateChatCompletion(context.Background(), ChatRequestBody{
+		Model:    GPT4o,
+		Messages: []*ChatMessage{{Role: RoleUser, Content: "hi"}},
+		Stream:   true,
+	})
+	if err != nil {
+		t.Fatalf("create chat completion error: %v", err)
+	}
+	var i int
+	for chunk := range res.StreamChan {
+		if string(chunk.RawJSON()) != chunks[i] {
+			t.Errorf("chunk %d: RawJSON = %s", i, chunk.RawJSON())
+		}
+		if got := string(chunk.UnknownFields()["obfuscation"]); got != map[int]string{0: `"a1"`}[i] {
+			t.Errorf("chunk %d: obfuscation = %q", i, got)
+		}
+		i++
+	}
+	if i != len(chunks) {
+		t.Fatalf("got %d chunks, want %d", i, len(chunks))
+	}
+}
+
+func TestExtraFields(t *testing.T) {
+	data, err := json.Marshal(ChatRequestBody{
+		Model:       GPT35Turbo,
+		Temperature: 1,
+		ExtraFields: map[string]any{"temperature": 0, "seed": 7},
+	})
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	var got map[string]any
+	_ = json.Unmarshal(data, &got)
+	want := map[string]any{"model": GPT35Turbo, "messages": nil, "temperature": float64(0), "seed": float64(7)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+
+	if data, err = json.Marshal(ModerationRequestBody{Input: "text"}); err != nil || string(data) != `{"input":"text"}` {
+		t.Errorf("moderation body = %s, %v", data, err)
+	}
+
+	// Merging keeps the precision of large integers.
+	data, err = json.Marshal(CompletionRequestBody{Model: "m", MaxTokens: 1<<53 + 1, ExtraFields: map[string]any{"seed": 7}})
+	if err != nil || !bytes.Contains(data, []byte(`"max_tokens":9007199254740993`)) {
+		t.Errorf("completion body = %s, %v", data, err)
+	}
+}
+
+func TestExtraFields_form(t *testing.T) {
+	var form map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse form error: %v", err)
+		} else {
+			form = r.MultipartForm.Value
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	_, err := c.CreateImageVariation(context.Background(), ImageVariationRequestBody{
+		Image:       "testdata/otter.png",
+		N:           1,
+		Size:        Size256,
+		ExtraFields: map[string]any{"model": "dall-e-2", "quality": "standard", "size": Size512},
+	}, WithExtraField("quality", "hd"), WithExtraField("n", 2))
+	if err != nil {
+		t.Fatalf("create image variation error: %v", err)
+	}
+	// The request option overrides the field of the body, and extra fields
+	// the fields of the body.
+	if got := form["quality"]; len(got) != 1 || got[0] != "hd" || len(form["model"]) != 1 {
+		t.Errorf("form = %v", form)
+	}
+	if n, size := form["n"], form["size"]; len(n) != 1 || n[0] != "2" || len(size) != 1 || size[0] != Size512 {
+		t.Errorf("form = %v", form)
+	}
+}
+
+func TestResponseMeta_cached(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-ada-002","encoding_format":"float"}`))
+	})
+	c.Use(CacheMiddleware(CacheOptions{Store: NewMemoryCache(0, 0)}))
+	body := EmbeddingsRequestBody{Model: TextEmbeddingAda002, Input: "hello"}
+	for i := 0; i < 2; i++ {
+		res, err := c.CreateEmbeddings(context.Background(), body)
+		if err != nil {
+			t.Fatalf("create embeddings error: %v", err)
+		}
+		if res.CacheHit != (i == 1) {
+			t.Errorf("call %d: CacheHit = %v", i, res.CacheHit)
+		}
+		if got := string(res.UnknownFields()["encoding_format"]); got != `"float"` {
+			t.Errorf("call %d: encoding_format = %q", i, got)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Endpoint is a family of API endpoints a model can be used with.
+type Endpoint string
+
+const (
+	EndpointChat        Endpoint = "chat"
+	EndpointCompletions Endpoint = "completions"
+	EndpointEdits       Endpoint = "edits"
+	EndpointEmbeddings  Endpoint = "embeddings"
+	EndpointAudio       Endpoint = "audio"
+	EndpointModerations Endpoint = "moderations"
+)
+
+// Modality is a kind of content a model accepts.
+type Modality string
+
+const (
+	ModalityText  Modality = "text"
+	ModalityImage Modality = "image"
+	ModalityAudio Modality = "audio"
+)
+
+// Pricing is the price of a model in US dollars per million tokens.
+type Pricing struct {
+	Input  float64
+	Output float64
+	// PerMinute is the price of a minute of audio, for models priced by
+	// duration rather than tokens.
+	PerMinute float64
+}
+
+// ModelInfo describes the capabilities of a model.
+type ModelInfo struct {
+	ID string
+	// Base is the model a fine-tuned model or an unlisted snapshot was
+	// derived from, "" for registered models.
+	Base string
+	// Endpoints lists the endpoint families the model can be used with. A
+	// model without endpoints is assumed to support every endpoint.
+	Endpoints  []Endpoint
+	Modalities []Modality
+	// ContextWindow is the number of tokens of the prompt and completion
+	// together, 0 when unknown.
+	ContextWindow int
+	// MaxOutputTokens is the number of tokens the model can generate, 0 when
+	// only the context window bounds it.
+	MaxOutputTokens int
+	// Pricing is zero when unknown.
+	Pricing Pricing
+	// DeprecationDate is when the model is shut down, zero when it is not
+	// deprecated. Replacement names the recommended model.
+	DeprecationDate time.Time
+	Replacement     string
+}
+
+// Supports reports whether the model can be used with an endpoint family.
+func (m ModelInfo) Supports(endpoint Endpoint) bool {
+	return len(m.Endpoints) == 0 || containsEndpoint(m.Endpoints, endpoint)
+}
+
+// Deprecated reports whether the model has a deprecation date.
+func (m ModelInfo) Deprecated() bool {
+	return !m.DeprecationDate.IsZero()
+}
+
+func containsEndpoint(endpoints []Endpoint, endpoint Endpoint) bool {
+	for _, e := range endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelRegistry describes the known models. Requests for a model the
+// registry knows are checked against its endpoints, requests for unknown
+// models are sent as is. It is safe for concurrent use.
+type ModelRegistry struct {
+	mu      sync.RWMutex
+	models  map[string]ModelInfo
+	aliases map[string]string
+	warned  map[string]bool
+}
+
+// DefaultModels is the registry used by clients without one. It knows the
+// OpenAI models and can be extended at runtime.
+var DefaultModels = newDefaultModels()
+
+// NewModelRegistry returns a registry of models.
+func NewModelRegistry(models ...ModelInfo) *ModelRegistry {
+	r := &ModelRegistry{
+		models:  make(map[string]ModelInfo),
+		aliases: make(map[string]string),
+		warned:  make(map[string]bool),
+	}
+	for _, m := range models {
+		r.Register(m)
+	}
+	return r
+}
+
+// Register adds a model, replacing a model or alias of the same ID.
+func (r *ModelRegistry) Register(info ModelInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.aliases, info.ID)
+	r.models[info.ID] = info
+}
+
+// Alias makes alias resolve to the model target, e.g. "gpt-4o" to its
+// current snapshot.
+func (r *ModelRegistry) Alias(alias, target string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias] = target
+}
+
+// Resolve follows the aliases of a model ID.
+func (r *ModelRegistry) Resolve(id string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resolve(id)
+}
+
+func (r *ModelRegistry) resolve(id string) string {
+	for i := 0; i < 8; i++ {
+		target, ok := r.aliases[id]
+		if !ok {
+			break
+		}
+		id = target
+	}
+	return id
+}
+
+var (
+	// fineTunedModel matches "ft:<base>:<org>:<suffix>:<id>" and the legacy
+	// "<base>:ft-<org>-<date>".
+	fineTunedModel = regexp.MustCompile(`^(?:ft:([^:]+):.*|([^:]+):ft-.*)$`)
+	// snapshotSuffix matches the date of snapshots such as "gpt-4-0613" and
+	// "gpt-4o-2024-08-06".
+	snapshotSuffix = regexp.MustCompile(`-(?:\d{4}|\d{4}-\d{2}-\d{2})$`)
+)
+
+// Lookup returns the description of a model, resolving aliases. Fine-tuned
+// models and unlisted snapshots are described by the model they derive from,
+// without its pricing and deprecation for fine-tuned models.
+func (r *ModelRegistry) Lookup(id string) (ModelInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if info, ok := r.models[r.resolve(id)]; ok {
+		return info, true
+	}
+	if m := fineTunedModel.FindStringSubmatch(id); m != nil {
+		base := m[1] + m[2]
+		if info, ok := r.models[r.resolve(base)]; ok {
+			// Fine-tuned models are priced and retired on their own.
+			info.ID, info.Base, info.Pricing = id, base, Pricing{}
+			info.DeprecationDate, info.Replacement = time.Time{}, ""
+			return info, true
+		}
+	}
+	if base := snapshotSuffix.ReplaceAllString(id, ""); base != id {
+		if info, ok := r.models[r.resolve(base)]; ok {
+			info.ID, info.Base = id, base
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// Models returns the registered models sorted by ID.
+func (r *ModelRegistry) Models() []ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models := make([]ModelInfo, 0, len(r.models))
+	for _, m := range r.models {
+		models = append(models, m)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models
+}
+
+// Load registers the models listed by the API that the registry does not
+// know yet, such as fine-tuned models. Their capabilities are unknown, so
+// they are accepted by every endpoint.
+func (r *ModelRegistry) Load(ctx context.Context, api ModelsAPI) error {
+	res, err := api.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range res.Data {
+		if _, ok := r.Lookup(m.ID); !ok {
+			r.Register(ModelInfo{ID: m.ID})
+		}
+	}
+	return nil
+}
+
+// warnOnce reports whether the deprecation of a model was not reported yet.
+func (r *ModelRegistry) warnOnce(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.warned[id] {
+		return false
+	}
+	r.warned[id] = true
+	return true
+}
+
+func (c *Client) models() *ModelRegistry {
+	if c.Models != nil {
+		return c.Models
+	}
+	return DefaultModels
+}
+
+var operationEndpoints = map[string]Endpoint{
+	"CreateChatCompletion": EndpointChat,
+	"CreateCompletions":    EndpointCompletions,
+	"CreateEdit":           EndpointEdits,
+	"CreateEmbeddings":     EndpointEmbeddings,
+	"CreateTranscription":  EndpointAudio,
+	"CreateTranslation":    EndpointAudio,
+	"CreateModeration":     EndpointModerations,
+}
+
+// checkModel rejects a model the registry knows does not support the
+// endpoint of an operation, and logs a warning the first time a deprecated
+// model is used.
+func (c *Client) checkModel(ctx context.Context, operation, model string) error {
+	endpoint, ok := operationEndpoints[operation]
+	if !ok || model == "" {
+		return nil
+	}
+	r := c.models()
+	info, ok := r.Lookup(model)
+	if !ok {
+		return nil
+	}
+	if !info.Supports(endpoint) {
+		return &ValidationError{Fields: []*FieldError{{
+			Field:   "model",
+			Message: fmt.Sprintf("%s does not support the %s endpoint", model, endpoint),
+			Err:     ErrInvalidModel,
+		}}}
+	}
+	if info.Deprecated() && c.Logger != nil && r.warnOnce(model) {
+		attrs := []any{
+			slog.String("model", model),
+			slog.String("deprecation_date", info.DeprecationDate.Format(time.DateOnly)),
+		}
+		if info.Replacement != "" {
+			attrs = append(attrs, slog.String("replacement", info.Replacement))
+		}
+		c.Logger.WarnContext(ctx, "openai: model is deprecated", attrs...)
+	}
+	return nil
+}
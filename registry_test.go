@@ -0,0 +1,117 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestModelRegistry_Lookup(t *testing.T) {
+	tests := []struct {
+		id, wantBase string
+		wantWindow   int
+		wantPricing  bool
+	}{
+		{GPT4, "", 8192, true},
+		{GPT4o, "", 128000, true},
+		{GPT35Turbo, "", 16385, true},
+		{"ft:gpt-3.5-turbo-0613:acme::7p4lURel", GPT35Turbo0613, 4096, false},
+		{"curie:ft-acme-2023-03-01-12-00-00", Curie, 2049, false},
+		{"gpt-4-32k-0125", GPT432k, 32768, true},
+		{"gpt-4o-mini-2030-01-01", GPT4oMini, 128000, true},
+	}
+	for _, tt := range tests {
+		info, ok := DefaultModels.Lookup(tt.id)
+		if !ok {
+			t.Errorf("Lookup(%q) found nothing", tt.id)
+			continue
+		}
+		if info.ID != tt.id && tt.wantBase != "" || info.Base != tt.wantBase || info.ContextWindow != tt.wantWindow ||
+			(info.Pricing != Pricing{}) != tt.wantPricing {
+			t.Errorf("Lookup(%q) = %+v", tt.id, info)
+		}
+	}
+	if _, ok := DefaultModels.Lookup("llama-3-70b"); ok {
+		t.Error("unknown model found")
+	}
+}
+
+func TestModelRegistry_extend(t *testing.T) {
+	r := NewModelRegistry(ModelInfo{ID: "local-chat", Endpoints: []Endpoint{EndpointChat}, ContextWindow: 2048})
+	r.Alias("default", "local-chat")
+	if got := r.Resolve("default"); got != "local-chat" {
+		t.Errorf("Resolve = %q", got)
+	}
+	if info, ok := r.Lookup("default"); !ok || info.ContextWindow != 2048 {
+		t.Errorf("Lookup(default) = %+v, %v", info, ok)
+	}
+
+	fake := &fakeModelsAPI{ids: []string{"local-chat", "local-embed", "ft:local-chat:me::1"}}
+	if err := r.Load(context.Background(), fake); err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	var ids []string
+	for _, m := range r.Models() {
+		ids = append(ids, m.ID)
+	}
+	if got := strings.Join(ids, ","); got != "local-chat,local-embed" {
+		t.Errorf("models = %s", got)
+	}
+	if info, _ := r.Lookup("local-embed"); !info.Supports(EndpointEmbeddings) {
+		t.Error("loaded model rejected by an endpoint")
+	}
+}
+
+type fakeModelsAPI struct {
+	ModelsAPI
+	ids []string
+}
+
+func (f *fakeModelsAPI) ListModels(context.Context, ...RequestOption) (*ModelsResponseBody, error) {
+	res := &ModelsResponseBody{}
+	for _, id := range f.ids {
+		res.Data = append(res.Data, ModelObject{ID: id})
+	}
+	return res, nil
+}
+
+func TestClient_checkModel(t *testing.T) {
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"chat.completion"}`))
+	})
+	var logs bytes.Buffer
+	c.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	c.Models = NewModelRegistry(builtinModels...)
+	messages := []*ChatMessage{{Role: RoleUser, Content: "hi"}}
+
+	for _, model := range []string{"ft:gpt-3.5-turbo-0613:acme::1", "my-own-model", GPT40314, GPT40314} {
+		if _, err := c.CreateChatCompletion(context.Background(), ChatRequestBody{Model: model, Messages: messages}); err != nil {
+			t.Errorf("%s: %v", model, err)
+		}
+	}
+	if requests != 4 {
+		t.Errorf("sent %d requests, want 4", requests)
+	}
+	if got := strings.Count(logs.String(), "model is deprecated"); got != 1 {
+		t.Errorf("got %d deprecation warnings, want 1:\n%s", got, logs.String())
+	}
+	if !strings.Contains(logs.String(), "deprecation_date=2024-06-13") {
+		t.Errorf("warning = %s", logs.String())
+	}
+
+	_, err := c.CreateChatCompletion(context.Background(), ChatRequestBody{Model: TextDavinci003, Messages: messages})
+	if !errors.Is(err, ErrInvalidModel) {
+		t.Errorf("completion model error = %v, want ErrInvalidModel", err)
+	}
+	_, err = c.CreateModeration(context.Background(), ModerationRequestBody{Model: GPT40314, Input: "hi"})
+	if !errors.Is(err, ErrInvalidModel) {
+		t.Errorf("chat model error = %v, want ErrInvalidModel", err)
+	}
+}
@@ -0,0 +1,111 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed requests are retried. Retries happen
+// below the middleware chain, so middlewares see a single call.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	// Requests are not retried when it is 1 or less.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled on each next
+	// one with jitter, 500 milliseconds when zero.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, including delays asked by
+	// a Retry-After header, 30 seconds when zero.
+	MaxBackoff time.Duration
+	// Retryable reports whether an error is retried. By default rate limits,
+	// server errors and network errors that happened before a response
+	// arrived are; a response that cannot be decoded is not, as the API may
+	// have billed the request.
+	Retryable func(err error) bool
+}
+
+// retryMiddleware retries the calls failing with a retryable error according
+// to the RetryPolicy of the call or of the client.
+func (c *Client) retryMiddleware(next Handler) Handler {
+	return func(call *Call) error {
+		policy := c.Retry
+		if call.options != nil && call.options.retry != nil {
+			policy = call.options.retry
+		}
+		if policy == nil || policy.MaxAttempts <= 1 {
+			return next(call)
+		}
+		retryable := policy.Retryable
+		if retryable == nil {
+			retryable = isRetryable
+		}
+
+		ctx := call.Context()
+		for attempt := 1; ; attempt++ {
+			err := next(call)
+			if err == nil || attempt >= policy.MaxAttempts || !retryable(err) || ctx.Err() != nil {
+				return err
+			}
+			if call.Request.Body != nil {
+				if call.Request.GetBody == nil {
+					return err
+				}
+				body, bodyErr := call.Request.GetBody()
+				if bodyErr != nil {
+					return err
+				}
+				call.Request.Body = body
+			}
+
+			timer := time.NewTimer(policy.backoff(attempt, call.Response))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+			call.Response = nil
+		}
+	}
+}
+
+// backoff returns the delay after the given failed attempt, honoring the
+// Retry-After header of its response.
+func (p *RetryPolicy) backoff(attempt int, res *http.Response) time.Duration {
+	minBackoff, maxBackoff := p.MinBackoff, p.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = 500 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	if res != nil {
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, maxBackoff)
+		}
+	}
+	d := minBackoff << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	// Wait between half and all of d so concurrent clients spread out.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// isRetryable is the default RetryPolicy.Retryable.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if status := statusCodeOf(err); status != 0 {
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+	// http.Client.Do returns a *url.Error when it got no response.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,231 @@
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+const (
+	R50KBase   = "r50k_base"
+	P50KBase   = "p50k_base"
+	P50KEdit   = "p50k_edit"
+	CL100KBase = "cl100k_base"
+	O200KBase  = "o200k_base"
+)
+
+const (
+	EndOfText   = "<|endoftext|>"
+	FIMPrefix   = "<|fim_prefix|>"
+	FIMMiddle   = "<|fim_middle|>"
+	FIMSuffix   = "<|fim_suffix|>"
+	EndOfPrompt = "<|endofprompt|>"
+)
+
+// ErrUnknownEncoding is returned for encodings and models the package does
+// not know.
+var ErrUnknownEncoding = errors.New("tokenizer: unknown encoding")
+
+// ErrNoRankFiles is returned by Get for an encoding whose rank file is
+// neither registered nor available from SetRankFiles.
+var ErrNoRankFiles = errors.New("tokenizer: no rank files, import github.com/im15/openai-api-go/tokenizer/ranks")
+
+// spec describes an encoding apart from its ranks.
+type spec struct {
+	file    string
+	split   splitFunc
+	special map[string]int
+}
+
+var specs = map[string]spec{
+	R50KBase: {
+		file:    R50KBase,
+		split:   splitR50K,
+		special: map[string]int{EndOfText: 50256},
+	},
+	P50KBase: {
+		file:    P50KBase,
+		split:   splitR50K,
+		special: map[string]int{EndOfText: 50256},
+	},
+	P50KEdit: {
+		file:  P50KBase,
+		split: splitR50K,
+		special: map[string]int{
+			EndOfText: 50256,
+			FIMPrefix: 50281,
+			FIMMiddle: 50282,
+			FIMSuffix: 50283,
+		},
+	},
+	CL100KBase: {
+		file:  CL100KBase,
+		split: splitCL100K,
+		special: map[string]int{
+			EndOfText:   100257,
+			FIMPrefix:   100258,
+			FIMMiddle:   100259,
+			FIMSuffix:   100260,
+			EndOfPrompt: 100276,
+		},
+	},
+	O200KBase: {
+		file:  O200KBase,
+		split: splitO200K,
+		special: map[string]int{
+			EndOfText:   199999,
+			EndOfPrompt: 200018,
+		},
+	},
+}
+
+var (
+	mu        sync.Mutex
+	encodings = make(map[string]*Encoding)
+	// rankFiles holds rank files named after their encoding with the
+	// extension .tiktoken.
+	rankFiles fs.FS
+)
+
+// SetRankFiles makes Get load the rank files from fsys, named after their
+// encoding with the extension .tiktoken, e.g. os.DirFS of a directory holding
+// them. Importing package ranks sets the rank files published by OpenAI,
+// embedded into the program. Encodings already loaded are kept.
+func SetRankFiles(fsys fs.FS) {
+	mu.Lock()
+	defer mu.Unlock()
+	rankFiles = fsys
+}
+
+// NewEncoding returns the encoding name with the ranks read from r, a rank
+// file of the tiktoken format such as
+// https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken.
+func NewEncoding(name string, r io.Reader) (*Encoding, error) {
+	s, ok := specs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncoding, name)
+	}
+	ranks, err := readRanks(r)
+	if err != nil {
+		return nil, err
+	}
+	special := make(map[string]int, len(s.special))
+	for token, id := range s.special {
+		special[token] = id
+	}
+	return newEncoding(name, s.split, ranks, special)
+}
+
+// Register makes Get and ForModel return e for its name.
+func Register(e *Encoding) {
+	mu.Lock()
+	defer mu.Unlock()
+	encodings[e.name] = e
+}
+
+// Get returns the encoding name, loading its rank file on first use.
+func Get(name string) (*Encoding, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if e, ok := encodings[name]; ok {
+		return e, nil
+	}
+	s, ok := specs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncoding, name)
+	}
+	if rankFiles == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoRankFiles, name)
+	}
+	f, err := rankFiles.Open(s.file + ".tiktoken")
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer: %s: %w", name, err)
+	}
+	defer f.Close()
+	e, err := NewEncoding(name, f)
+	if err != nil {
+		return nil, err
+	}
+	encodings[name] = e
+	return e, nil
+}
+
+// MustGet is like Get but panics on error.
+func MustGet(name string) *Encoding {
+	e, err := Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// ForModel returns the encoding of a model.
+func ForModel(model string) (*Encoding, error) {
+	name, ok := EncodingNameForModel(model)
+	if !ok {
+		return nil, fmt.Errorf("%w: no encoding for model %s", ErrUnknownEncoding, model)
+	}
+	return Get(name)
+}
+
+var modelEncodings = map[string]string{
+	"davinci-002":            CL100KBase,
+	"babbage-002":            CL100KBase,
+	"text-embedding-ada-002": CL100KBase,
+	"text-davinci-003":       P50KBase,
+	"text-davinci-002":       P50KBase,
+	"text-davinci-001":       R50KBase,
+	"text-curie-001":         R50KBase,
+	"text-babbage-001":       R50KBase,
+	"text-ada-001":           R50KBase,
+	"davinci":                R50KBase,
+	"curie":                  R50KBase,
+	"babbage":                R50KBase,
+	"ada":                    R50KBase,
+	"code-davinci-002":       P50KBase,
+	"code-davinci-001":       P50KBase,
+	"code-cushman-002":       P50KBase,
+	"code-cushman-001":       P50KBase,
+	"text-davinci-edit-001":  P50KEdit,
+	"code-davinci-edit-001":  P50KEdit,
+}
+
+// modelPrefixEncodings is searched in order, longer prefixes first.
+var modelPrefixEncodings = []struct{ prefix, name string }{
+	{"gpt-4o", O200KBase},
+	{"chatgpt-4o", O200KBase},
+	{"gpt-4.1", O200KBase},
+	{"gpt-4.5", O200KBase},
+	{"o1", O200KBase},
+	{"o3", O200KBase},
+	{"o4", O200KBase},
+	{"gpt-4", CL100KBase},
+	{"gpt-3.5-turbo", CL100KBase},
+	{"gpt-35-turbo", CL100KBase},
+	{"text-embedding-3-", CL100KBase},
+	{"text-similarity-", R50KBase},
+	{"text-search-", R50KBase},
+	{"code-search-", R50KBase},
+}
+
+// EncodingNameForModel returns the name of the encoding of a model. The
+// base model names fine-tuned models, e.g. "ft:gpt-4o-mini:org::id".
+func EncodingNameForModel(model string) (string, bool) {
+	if rest, ok := strings.CutPrefix(model, "ft:"); ok {
+		model, _, _ = strings.Cut(rest, ":")
+	} else if base, _, ok := strings.Cut(model, ":ft-"); ok {
+		model = base
+	}
+	if name, ok := modelEncodings[model]; ok {
+		return name, true
+	}
+	for _, p := range modelPrefixEncodings {
+		if strings.HasPrefix(model, p.prefix) {
+			return p.name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+//go:build ignore
+
+// Command gen downloads the rank files of the encodings into data, checking
+// them against the hashes the tiktoken library publishes.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const baseURL = "https://openaipublic.blob.core.windows.net/encodings/"
+
+var files = map[string]string{
+	"r50k_base":   "306cd27f03c1a714eca7108e03d66b7dc042abe8c258b44c199a7ed9838dd930",
+	"p50k_base":   "94b5ca7dff4d00767bc256fdd1b27e5b17361d7b8a5f968547f9f23eb70d2069",
+	"cl100k_base": "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7",
+	"o200k_base":  "446a9538cb6c348e3516120d7c08b09f57c36495e2acfffe59a5bf8b0cfb1a2d",
+}
+
+func main() {
+	for name, hash := range files {
+		if err := download(name, hash); err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+func download(name, hash string) error {
+	res, err := http.Get(baseURL + name + ".tiktoken")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != hash {
+		return fmt.Errorf("sha256 %s, expected %s", got, hash)
+	}
+	return os.WriteFile(filepath.Join("data", name+".tiktoken"), data, 0o644)
+}
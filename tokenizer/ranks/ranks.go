@@ -0,0 +1,30 @@
+// Package ranks embeds the rank files of the encodings published by OpenAI
+// and makes package tokenizer load them. It is imported for its side effect:
+//
+//	import _ "github.com/im15/openai-api-go/tokenizer/ranks"
+//
+// The files add about 7 MB to the program.
+package ranks
+
+//go:generate go run gen.go
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/im15/openai-api-go/tokenizer"
+)
+
+// files holds the rank files, named after their encoding with the extension
+// .tiktoken. go generate downloads them again, checking their hashes.
+//
+//go:embed data/*.tiktoken
+var files embed.FS
+
+func init() {
+	data, err := fs.Sub(files, "data")
+	if err != nil {
+		panic(err)
+	}
+	tokenizer.SetRankFiles(data)
+}
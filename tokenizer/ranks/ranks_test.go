@@ -0,0 +1,20 @@
+package ranks_test
+
+import (
+	"testing"
+
+	"github.com/im15/openai-api-go/tokenizer"
+	_ "github.com/im15/openai-api-go/tokenizer/ranks"
+)
+
+func TestRanks(t *testing.T) {
+	for _, name := range []string{tokenizer.R50KBase, tokenizer.P50KBase, tokenizer.CL100KBase, tokenizer.O200KBase} {
+		e, err := tokenizer.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := e.Count("hello world"); n != 2 {
+			t.Errorf("%s: Count() = %d, want 2", name, n)
+		}
+	}
+}
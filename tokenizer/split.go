@@ -0,0 +1,289 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// A splitFunc returns the length in bytes of the first piece of a non-empty
+// string. Pieces are the words, numbers, punctuation and whitespace the text
+// is split into before byte pair encoding.
+//
+// The split functions replicate the regular expressions of the reference
+// implementation, which rely on a look-ahead Go's regexp package does not
+// support. Each tries the alternatives of its expression in order and
+// returns the first match, as a backtracking regexp engine would.
+type splitFunc func(s string) int
+
+// splitR50K implements
+//
+//	's|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+
+func splitR50K(s string) int {
+	if n := contraction(s, false); n > 0 {
+		return n
+	}
+	if n := spaceRun(s, isLetter); n > 0 {
+		return n
+	}
+	if n := spaceRun(s, isNumber); n > 0 {
+		return n
+	}
+	if n := spaceRun(s, isPunct); n > 0 {
+		return n
+	}
+	return whitespace(s, false)
+}
+
+// splitCL100K implements
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitCL100K(s string) int {
+	if n := contraction(s, true); n > 0 {
+		return n
+	}
+	if n := prefixedRun(s, isLetter); n > 0 {
+		return n
+	}
+	if n := digits(s); n > 0 {
+		return n
+	}
+	if n := punctuation(s, isNewline); n > 0 {
+		return n
+	}
+	return whitespace(s, true)
+}
+
+// splitO200K implements
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitO200K(s string) int {
+	if n := casedWord(s, false); n > 0 {
+		return n
+	}
+	if n := casedWord(s, true); n > 0 {
+		return n
+	}
+	if n := digits(s); n > 0 {
+		return n
+	}
+	if n := punctuation(s, isNewlineOrSlash); n > 0 {
+		return n
+	}
+	return whitespace(s, true)
+}
+
+var contractions = []string{"s", "t", "re", "ve", "m", "ll", "d"}
+
+// contraction matches '(s|t|re|ve|m|ll|d), ignoring case when fold is set.
+func contraction(s string, fold bool) int {
+	if len(s) < 2 || s[0] != '\'' {
+		return 0
+	}
+	for _, c := range contractions {
+		if len(s) > len(c) && (s[1:1+len(c)] == c || fold && asciiEqualFold(s[1:1+len(c)], c)) {
+			return 1 + len(c)
+		}
+	}
+	return 0
+}
+
+func asciiEqualFold(s, lower string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i]|0x20 != lower[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// run returns the length of the longest prefix of s whose runes are in.
+func run(s string, in func(rune) bool) int {
+	n := 0
+	for n < len(s) {
+		r, w := decodeRune(s[n:])
+		if !in(r) {
+			break
+		}
+		n += w
+	}
+	return n
+}
+
+// spaceRun matches " ?X+".
+func spaceRun(s string, in func(rune) bool) int {
+	if s[0] == ' ' {
+		if n := run(s[1:], in); n > 0 {
+			return 1 + n
+		}
+	}
+	return run(s, in)
+}
+
+// prefixedRun matches "[^\r\n\p{L}\p{N}]?X+".
+func prefixedRun(s string, in func(rune) bool) int {
+	if r, w := decodeRune(s); isPrefix(r) {
+		if n := run(s[w:], in); n > 0 {
+			return w + n
+		}
+	}
+	return run(s, in)
+}
+
+// digits matches "\p{N}{1,3}".
+func digits(s string) int {
+	n := 0
+	for i := 0; i < 3 && n < len(s); i++ {
+		r, w := decodeRune(s[n:])
+		if !isNumber(r) {
+			break
+		}
+		n += w
+	}
+	return n
+}
+
+// punctuation matches " ?[^\s\p{L}\p{N}]+T*" where T is tail.
+func punctuation(s string, tail func(rune) bool) int {
+	n := spaceRun(s, isPunct)
+	if n == 0 {
+		return 0
+	}
+	return n + run(s[n:], tail)
+}
+
+// whitespace matches "\s*[\r\n]+|\s+(?!\S)|\s+", without the first
+// alternative unless newlines is set.
+func whitespace(s string, newlines bool) int {
+	end, afterNewline := 0, 0
+	for end < len(s) {
+		r, w := decodeRune(s[end:])
+		if !isSpace(r) {
+			break
+		}
+		end += w
+		if isNewline(r) {
+			afterNewline = end
+		}
+	}
+	switch {
+	case newlines && afterNewline > 0:
+		return afterNewline
+	case end == 0:
+		// Not reached for valid splitters, every rune matches an
+		// alternative; consume a rune to guarantee progress.
+		_, w := decodeRune(s)
+		return w
+	case end == len(s):
+		return end
+	}
+	// Leave the last space to the next piece, which it prefixes.
+	if _, w := utf8.DecodeLastRuneInString(s[:end]); end > w {
+		return end - w
+	}
+	return end
+}
+
+// casedWord matches the first alternative of the o200k_base expression,
+// "prefix? upper* lower+ contraction?", or the second when upperFirst is set,
+// "prefix? upper+ lower* contraction?".
+func casedWord(s string, upperFirst bool) int {
+	if r, w := decodeRune(s); isPrefix(r) && w < len(s) {
+		if n := casedWordBody(s[w:], upperFirst); n > 0 {
+			return w + n
+		}
+	}
+	return casedWordBody(s, upperFirst)
+}
+
+func casedWordBody(s string, upperFirst bool) int {
+	// upper and lower overlap: modifier and other letters and marks are in
+	// both, so upper* may have to give back its last runes to lower+.
+	upper, afterLower := 0, 0
+	for upper < len(s) {
+		r, w := decodeRune(s[upper:])
+		if !isUpper(r) {
+			break
+		}
+		upper += w
+		if isLower(r) {
+			afterLower = upper
+		}
+	}
+	n := 0
+	switch lower := run(s[upper:], isLower); {
+	case upperFirst && upper == 0:
+		return 0
+	case upperFirst || lower > 0:
+		n = upper + lower
+	case afterLower > 0:
+		n = afterLower
+	default:
+		return 0
+	}
+	return n + contraction(s[n:], true)
+}
+
+func decodeRune(s string) (rune, int) {
+	if c := s[0]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeRuneInString(s)
+}
+
+func isLetter(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r|0x20 && r|0x20 <= 'z'
+	}
+	return unicode.IsLetter(r)
+}
+
+func isNumber(r rune) bool {
+	if r < utf8.RuneSelf {
+		return '0' <= r && r <= '9'
+	}
+	return unicode.IsNumber(r)
+}
+
+func isSpace(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r == ' ' || '\t' <= r && r <= '\r'
+	}
+	return unicode.IsSpace(r)
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isNewlineOrSlash(r rune) bool {
+	return r == '\r' || r == '\n' || r == '/'
+}
+
+// isPunct reports whether r is in [^\s\p{L}\p{N}].
+func isPunct(r rune) bool {
+	return !isSpace(r) && !isLetter(r) && !isNumber(r)
+}
+
+// isPrefix reports whether r is in [^\r\n\p{L}\p{N}].
+func isPrefix(r rune) bool {
+	return !isNewline(r) && !isLetter(r) && !isNumber(r)
+}
+
+// isUpper reports whether r is in [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}].
+func isUpper(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'A' <= r && r <= 'Z'
+	}
+	return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// isLower reports whether r is in [\p{Ll}\p{Lm}\p{Lo}\p{M}].
+func isLower(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r && r <= 'z'
+	}
+	return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
@@ -0,0 +1,61 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func pieces(split splitFunc, text string) []string {
+	var p []string
+	for text != "" {
+		n := split(text)
+		p = append(p, text[:n])
+		text = text[n:]
+	}
+	return p
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		split splitFunc
+		text  string
+		want  []string
+	}{
+		{"r50k", splitR50K, "hello world 123!!", []string{"hello", " world", " 123", "!!"}},
+		{"r50k case", splitR50K, "I'M  ok", []string{"I", "'", "M", " ", " ok"}},
+		{"cl100k", splitCL100K, "hello world!你好，世界！", []string{"hello", " world", "!你好", "，世界", "！"}},
+		{"cl100k contraction", splitCL100K, "I'm  fine\n\n  ok", []string{"I", "'m", " ", " fine", "\n\n", " ", " ok"}},
+		{"cl100k upper contraction", splitCL100K, "HELLO'S", []string{"HELLO", "'S"}},
+		{"cl100k digits", splitCL100K, "12345", []string{"123", "45"}},
+		{"cl100k punctuation", splitCL100K, "foo...\n\nbar", []string{"foo", "...\n\n", "bar"}},
+		{"cl100k trailing space", splitCL100K, "x   ", []string{"x", "   "}},
+		{"cl100k tab prefix", splitCL100K, "a\tb", []string{"a", "\tb"}},
+		{"o200k camel case", splitO200K, "HelloWorld", []string{"Hello", "World"}},
+		{"o200k upper contraction", splitO200K, "HELLO'S", []string{"HELLO'S"}},
+		{"o200k contraction", splitO200K, " don't", []string{" don't"}},
+		{"o200k slash", splitO200K, "a/b//\n", []string{"a", "/b", "//\n"}},
+		{"o200k modifier letter", splitO200K, "Aʰb", []string{"Aʰb"}},
+		{"o200k unicode", splitO200K, "Привет мир", []string{"Привет", " мир"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pieces(tt.split, tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pieces(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit_invalidUTF8(t *testing.T) {
+	text := "a\xffb\xc3"
+	for _, split := range []splitFunc{splitR50K, splitCL100K, splitO200K} {
+		got := ""
+		for _, p := range pieces(split, text) {
+			got += p
+		}
+		if got != text {
+			t.Errorf("pieces join to %q", got)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Package tokenizer counts, encodes and decodes the tokens of OpenAI models
+// offline, with the byte pair encodings of the tiktoken library.
+//
+// The rank files of the encodings, 7 MB, are not part of the package. Import
+// package ranks to embed them into the program, or load them from disk with
+// SetRankFiles:
+//
+//	import _ "github.com/im15/openai-api-go/tokenizer/ranks"
+//
+//	enc, err := tokenizer.ForModel(openai.GPT4o)
+//	if err != nil {
+//		...
+//	}
+//	n := enc.Count("hello world")
+//
+// Encodings are safe for concurrent use.
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Encoding is a byte pair encoding.
+type Encoding struct {
+	name    string
+	split   splitFunc
+	ranks   map[string]int
+	decoder [][]byte
+	// special maps the special tokens to their IDs. specialNames lists
+	// them longest first, so that the longest match wins.
+	special      map[string]int
+	specialNames []string
+
+	parts sync.Pool
+}
+
+// part is a token boundary within a piece and the rank of merging the
+// tokens on both sides of the next boundary.
+type part struct {
+	start int
+	rank  int
+}
+
+const noRank = math.MaxInt
+
+// newEncoding returns an Encoding of the ranks and special tokens.
+func newEncoding(name string, split splitFunc, ranks map[string]int, special map[string]int) (*Encoding, error) {
+	// Ranks may leave gaps for the special tokens, e.g. p50k_base.
+	size := 0
+	for _, rank := range ranks {
+		size = max(size, rank+1)
+	}
+	for _, id := range special {
+		size = max(size, id+1)
+	}
+	e := &Encoding{
+		name:    name,
+		split:   split,
+		ranks:   ranks,
+		decoder: make([][]byte, size),
+		special: special,
+	}
+	for token, rank := range ranks {
+		if rank < 0 || e.decoder[rank] != nil {
+			return nil, fmt.Errorf("tokenizer: invalid rank %d in %s", rank, name)
+		}
+		e.decoder[rank] = []byte(token)
+	}
+	for i := 0; i < 256; i++ {
+		if _, ok := ranks[string([]byte{byte(i)})]; !ok {
+			return nil, fmt.Errorf("tokenizer: %s has no token for byte %#x", name, i)
+		}
+	}
+	for token, id := range special {
+		e.decoder[id] = []byte(token)
+		e.specialNames = append(e.specialNames, token)
+	}
+	sort.Slice(e.specialNames, func(i, j int) bool {
+		return len(e.specialNames[i]) > len(e.specialNames[j])
+	})
+	e.parts.New = func() any { return new([]part) }
+	return e, nil
+}
+
+// readRanks reads a rank file of the tiktoken format, which has a line of
+// the base64 encoded token and its rank per token.
+func readRanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int, 1<<17)
+	s := bufio.NewScanner(r)
+	var buf []byte
+	for line := 1; s.Scan(); line++ {
+		field := bytes.Fields(s.Bytes())
+		if len(field) == 0 {
+			continue
+		}
+		if len(field) != 2 {
+			return nil, fmt.Errorf("tokenizer: line %d: expected a token and its rank", line)
+		}
+		if size := base64.StdEncoding.DecodedLen(len(field[0])); cap(buf) < size {
+			buf = make([]byte, size)
+		}
+		n, err := base64.StdEncoding.Decode(buf[:cap(buf)], field[0])
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer: line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(string(field[1]))
+		if err != nil {
+			return nil, fmt.Errorf("tokenizer: line %d: %w", line, err)
+		}
+		ranks[string(buf[:n])] = rank
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, errors.New("tokenizer: empty rank file")
+	}
+	return ranks, nil
+}
+
+// Name returns the name of the encoding, e.g. "cl100k_base".
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// VocabularySize returns the number of tokens, special tokens included.
+func (e *Encoding) VocabularySize() int {
+	return len(e.decoder)
+}
+
+// SpecialTokens returns the IDs of the special tokens by name, such as
+// "<|endoftext|>".
+func (e *Encoding) SpecialTokens() map[string]int {
+	special := make(map[string]int, len(e.special))
+	for token, id := range e.special {
+		special[token] = id
+	}
+	return special
+}
+
+// Encode returns the tokens of text. Special tokens in the text are encoded
+// as ordinary text, which is safe for text from users.
+func (e *Encoding) Encode(text string) []int {
+	tokens := make([]int, 0, len(text)/4+1)
+	e.encode(text, func(token, _ int) { tokens = append(tokens, token) })
+	return tokens
+}
+
+// EncodeWithSpecial returns the tokens of text, encoding the special tokens
+// that occur in it as such.
+func (e *Encoding) EncodeWithSpecial(text string) []int {
+	tokens := make([]int, 0, len(text)/4+1)
+	for text != "" {
+		i, special := e.nextSpecial(text)
+		e.encode(text[:i], func(token, _ int) { tokens = append(tokens, token) })
+		if special == "" {
+			break
+		}
+		tokens = append(tokens, e.special[special])
+		text = text[i+len(special):]
+	}
+	return tokens
+}
+
+// nextSpecial returns the first special token in text and its index, or ""
+// and len(text).
+func (e *Encoding) nextSpecial(text string) (int, string) {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '<' {
+			continue
+		}
+		for _, special := range e.specialNames {
+			if strings.HasPrefix(text[i:], special) {
+				return i, special
+			}
+		}
+	}
+	return len(text), ""
+}
+
+// Count returns the number of tokens of text, encoding special tokens as
+// ordinary text like Encode. It does not allocate the tokens.
+func (e *Encoding) Count(text string) int {
+	n := 0
+	e.encode(text, func(int, int) { n++ })
+	return n
+}
+
+// Decode returns the text of tokens. Tokens that split a multi-byte
+// character yield invalid UTF-8; tokens out of range are skipped.
+func (e *Encoding) Decode(tokens []int) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		if token >= 0 && token < len(e.decoder) {
+			b.Write(e.decoder[token])
+		}
+	}
+	return b.String()
+}
+
+// DecodeToken returns the bytes of a token, nil if it is out of range.
+func (e *Encoding) DecodeToken(token int) []byte {
+	if token < 0 || token >= len(e.decoder) {
+		return nil
+	}
+	return e.decoder[token]
+}
+
+// Truncate returns the longest prefix of text that encodes to at most n
+// tokens and ends at a token and character boundary.
+func (e *Encoding) Truncate(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	end, count := 0, 0
+	e.encode(text, func(_, size int) {
+		if count < n {
+			end += size
+		}
+		count++
+	})
+	if count <= n {
+		return text
+	}
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	// The last pieces of a prefix may encode differently than within the
+	// text, which rarely takes more tokens.
+	for end > 0 && e.Count(text[:end]) > n {
+		_, w := utf8.DecodeLastRuneInString(text[:end])
+		end -= w
+	}
+	return text[:end]
+}
+
+// encode calls yield with every ordinary token of text and its size in
+// bytes.
+func (e *Encoding) encode(text string, yield func(token, size int)) {
+	for text != "" {
+		n := e.split(text)
+		piece := text[:n]
+		text = text[n:]
+		if rank, ok := e.ranks[piece]; ok {
+			yield(rank, n)
+			continue
+		}
+		e.bytePairEncode(piece, yield)
+	}
+}
+
+// bytePairEncode merges the bytes of piece into tokens, merging the pair
+// of adjacent tokens of the lowest rank first.
+func (e *Encoding) bytePairEncode(piece string, yield func(token, size int)) {
+	p := e.parts.Get().(*[]part)
+	parts := (*p)[:0]
+	for i := 0; i <= len(piece); i++ {
+		parts = append(parts, part{start: i, rank: noRank})
+	}
+	// rank returns the rank of the token merging parts i and i+1.
+	rank := func(i int) int {
+		if i+2 < len(parts) {
+			if r, ok := e.ranks[piece[parts[i].start:parts[i+2].start]]; ok {
+				return r
+			}
+		}
+		return noRank
+	}
+	for i := 0; i+2 < len(parts); i++ {
+		parts[i].rank = rank(i)
+	}
+	for len(parts) > 2 {
+		best, min := -1, noRank
+		for i := 0; i+1 < len(parts); i++ {
+			if parts[i].rank < min {
+				best, min = i, parts[i].rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = append(parts[:best+1], parts[best+2:]...)
+		parts[best].rank = rank(best)
+		if best > 0 {
+			parts[best-1].rank = rank(best - 1)
+		}
+	}
+	for i := 0; i+1 < len(parts); i++ {
+		token := piece[parts[i].start:parts[i+1].start]
+		yield(e.ranks[token], len(token))
+	}
+	*p = parts
+	e.parts.Put(p)
+}
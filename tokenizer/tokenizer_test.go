@@ -0,0 +1,239 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testRanks returns the ranks of every byte followed by tokens.
+func testRanks(tokens ...string) map[string]int {
+	ranks := make(map[string]int)
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	for i, token := range tokens {
+		ranks[token] = 256 + i
+	}
+	return ranks
+}
+
+func testEncoding(t testing.TB) *Encoding {
+	t.Helper()
+	ranks := testRanks("he", "ll", "hell", "hello", " w", "or", " wor", "ld", " world")
+	e, err := newEncoding("test", splitCL100K, ranks, map[string]int{EndOfText: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEncoding_Encode(t *testing.T) {
+	e := testEncoding(t)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"", []int{}},
+		{"hello world", []int{259, 264}},
+		{"hellx", []int{258, 'x'}},
+		{"helo", []int{256, 'l', 'o'}},
+		{" worlds", []int{264, 's'}},
+		{" wold", []int{260, 'o', 263}},
+		{"<|endoftext|>", []int{'<', '|', 'e', 'n', 'd', 'o', 'f', 't', 'e', 'x', 't', '|', '>'}},
+	}
+	for _, tt := range tests {
+		if got := e.Encode(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+		if got := e.Count(tt.text); got != len(tt.want) {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, len(tt.want))
+		}
+	}
+	if got := e.Count(EndOfText); got == 1 {
+		t.Error("special token counted as such")
+	}
+}
+
+func TestEncoding_EncodeWithSpecial(t *testing.T) {
+	e := testEncoding(t)
+	got := e.EncodeWithSpecial("hello<|endoftext|> world<|endoftext|>")
+	want := []int{259, 1000, 264, 1000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EncodeWithSpecial() = %v, want %v", got, want)
+	}
+	if got := e.Decode(got); got != "hello<|endoftext|> world<|endoftext|>" {
+		t.Errorf("Decode() = %q", got)
+	}
+}
+
+func TestEncoding_Decode(t *testing.T) {
+	e := testEncoding(t)
+	for _, text := range []string{"hello world", "héllo, wörld! 你好 🙂\n\n  x", "a\xffb"} {
+		if got := e.Decode(e.Encode(text)); got != text {
+			t.Errorf("Decode(Encode(%q)) = %q", text, got)
+		}
+	}
+	if got := e.Decode([]int{-1, 259, 5000}); got != "hello" {
+		t.Errorf("Decode() skipped nothing: %q", got)
+	}
+}
+
+func TestEncoding_Truncate(t *testing.T) {
+	e := testEncoding(t)
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"hello world", 0, ""},
+		{"hello world", 1, "hello"},
+		{"hello world", 2, "hello world"},
+		{"hello world", 5, "hello world"},
+		{"hello wor", 2, "hello wor"},
+		// "é" takes two byte tokens, the prefix must not split it.
+		{"hellé", 2, "hell"},
+	}
+	for _, tt := range tests {
+		got := e.Truncate(tt.text, tt.n)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+		if e.Count(got) > tt.n {
+			t.Errorf("Truncate(%q, %d) has %d tokens", tt.text, tt.n, e.Count(got))
+		}
+	}
+}
+
+func TestNewEncoding(t *testing.T) {
+	var b strings.Builder
+	for token, rank := range testRanks("he", "ll") {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	e, err := NewEncoding(CL100KBase, strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Encode("hell"); !reflect.DeepEqual(got, []int{256, 257}) {
+		t.Errorf("Encode() = %v", got)
+	}
+	if e.SpecialTokens()[EndOfText] != 100257 {
+		t.Errorf("SpecialTokens() = %v", e.SpecialTokens())
+	}
+
+	if _, err := NewEncoding("gpt2", strings.NewReader(b.String())); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("unknown encoding: %v", err)
+	}
+	if _, err := NewEncoding(CL100KBase, strings.NewReader("aGU= 0\n")); err == nil {
+		t.Error("rank file without byte tokens accepted")
+	}
+	if _, err := NewEncoding(CL100KBase, strings.NewReader("!!! 0\n")); err == nil {
+		t.Error("invalid base64 accepted")
+	}
+}
+
+func TestEncodingNameForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o":                         O200KBase,
+		"gpt-4o-mini-2024-07-18":         O200KBase,
+		"gpt-4-0613":                     CL100KBase,
+		"gpt-3.5-turbo-16k":              CL100KBase,
+		"text-embedding-3-small":         CL100KBase,
+		"text-davinci-003":               P50KBase,
+		"text-davinci-edit-001":          P50KEdit,
+		"davinci":                        R50KBase,
+		"davinci-002":                    CL100KBase,
+		"ft:gpt-4o-mini:acme::abc123":    O200KBase,
+		"curie:ft-acme-2023-03-01-12-00": R50KBase,
+	}
+	for model, want := range tests {
+		if got, ok := EncodingNameForModel(model); !ok || got != want {
+			t.Errorf("EncodingNameForModel(%q) = %q, %v, want %q", model, got, ok, want)
+		}
+	}
+	if _, err := ForModel("llama-3"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("ForModel(llama-3) = %v", err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Package ranks imports this one, so the tests read its files directly.
+	SetRankFiles(os.DirFS("ranks/data"))
+	os.Exit(m.Run())
+}
+
+func TestGet_noRankFiles(t *testing.T) {
+	mu.Lock()
+	saved := rankFiles
+	delete(encodings, P50KEdit)
+	mu.Unlock()
+	defer SetRankFiles(saved)
+
+	SetRankFiles(nil)
+	if _, err := Get(P50KEdit); !errors.Is(err, ErrNoRankFiles) {
+		t.Errorf("Get() without rank files = %v, want ErrNoRankFiles", err)
+	}
+	SetRankFiles(fstest.MapFS{})
+	if _, err := Get(P50KEdit); err == nil {
+		t.Error("Get() with a missing rank file succeeded")
+	}
+}
+
+// embedded returns an encoding loaded from the rank files of package ranks.
+func embedded(t testing.TB, name string) *Encoding {
+	t.Helper()
+	e, err := Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestGet_reference(t *testing.T) {
+	tests := []struct {
+		encoding, text string
+		special        bool
+		want           []int
+	}{
+		{R50KBase, "hello world", false, []int{31373, 995}},
+		{P50KBase, "hello world", false, []int{31373, 995}},
+		{CL100KBase, "hello world", false, []int{15339, 1917}},
+		{CL100KBase, "tiktoken is great!", false, []int{83, 1609, 5963, 374, 2294, 0}},
+		{CL100KBase, "hello world!你好，世界！", false, []int{15339, 1917, 0, 57668, 53901, 3922, 3574, 244, 98220, 6447}},
+		{CL100KBase, "hello <|endoftext|>", true, []int{15339, 220, 100257}},
+		{O200KBase, "hello world", false, []int{24912, 2375}},
+		{O200KBase, "tiktoken is great!", false, []int{83, 8251, 2488, 382, 2212, 0}},
+		{O200KBase, "hello <|endoftext|>", true, []int{24912, 220, 199999}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding+"/"+tt.text, func(t *testing.T) {
+			e := embedded(t, tt.encoding)
+			got := e.Encode(tt.text)
+			if tt.special {
+				got = e.EncodeWithSpecial(tt.text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if got := e.Decode(tt.want); got != tt.text {
+				t.Errorf("Decode() = %q", got)
+			}
+		})
+	}
+}
+
+var benchmarkText = strings.Repeat("The quick brown fox jumps over the lazy dog. Don't panic: 42 × 1337 = 56154!\n", 64)
+
+func BenchmarkEncoding_Count(b *testing.B) {
+	e := embedded(b, CL100KBase)
+	b.SetBytes(int64(len(benchmarkText)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e.Count(benchmarkText)
+	}
+}
@@ -0,0 +1,259 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"strings"
+
+	"github.com/im15/openai-api-go/tokenizer"
+)
+
+// ErrContextWindowExceeded is returned when a prompt leaves no room for the
+// completion in the context window of the model.
+var ErrContextWindowExceeded = errors.New("context window exceeded")
+
+// The overhead of the chat format in tokens, as counted by the API.
+const (
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	// tokensPerReply primes the assistant reply.
+	tokensPerReply = 3
+
+	// gpt-3.5-turbo-0301 formats messages differently.
+	tokensPerMessage0301 = 4
+	tokensPerName0301    = -1
+
+	tokensPerToolCall = 3
+
+	toolsBase       = 12
+	toolFunction    = 7
+	toolProperties  = 3
+	toolProperty    = 3
+	toolEnum        = -3
+	toolEnumItem    = 3
+	imageLowDetail  = 85
+	imageTileTokens = 170
+)
+
+// CountPromptTokens predicts the prompt tokens the API reports in
+// Usage.PromptTokens for a chat completion request.
+//
+// Text messages are counted exactly. Tool definitions are counted from the
+// name, type, description and enum of their parameters, and may be off by a
+// few tokens for schemas using other keywords. The tokens of an image depend
+// on its size, which is read from data URLs; images the API downloads are
+// counted at the most a high detail image can take.
+//
+// The rank files of the encodings must be loaded, e.g. by importing package
+// tokenizer/ranks.
+func CountPromptTokens(body ChatRequestBody) (int, error) {
+	enc, err := tokenizer.ForModel(body.Model)
+	if err != nil {
+		return 0, err
+	}
+	return countPromptTokens(enc, body)
+}
+
+// tokenCounter counts the tokens of text, e.g. a *tokenizer.Encoding.
+type tokenCounter interface {
+	Count(text string) int
+}
+
+// byteCounter counts a token per byte, which bounds the tokens of any byte
+// pair encoding.
+type byteCounter struct{}
+
+func (byteCounter) Count(text string) int {
+	return len(text)
+}
+
+// counterFor returns the encoding of a model, or a byteCounter for models
+// without a known encoding or whose rank file is not loaded.
+func counterFor(model string) tokenCounter {
+	if enc, err := tokenizer.ForModel(model); err == nil {
+		return enc
+	}
+	return byteCounter{}
+}
+
+func countPromptTokens(enc tokenCounter, body ChatRequestBody) (int, error) {
+	perMessage, perName := tokensPerMessage, tokensPerName
+	if strings.HasPrefix(body.Model, GPT35Turbo0301) {
+		perMessage, perName = tokensPerMessage0301, tokensPerName0301
+	}
+	n := tokensPerReply
+	for _, m := range body.Messages {
+		if m == nil {
+			continue
+		}
+		n += perMessage + enc.Count(m.Role) + enc.Count(m.Content)
+		if m.Name != "" {
+			n += perName + enc.Count(m.Name)
+		}
+		for _, p := range m.MultiContent {
+			switch p.Type {
+			case ChatMessagePartTypeText:
+				n += enc.Count(p.Text)
+			case ChatMessagePartTypeImageURL:
+				if p.ImageURL == nil {
+					continue
+				}
+				tokens, err := imageURLTokens(p.ImageURL)
+				if err != nil {
+					return 0, err
+				}
+				n += tokens
+			}
+		}
+		for _, call := range m.ToolCalls {
+			n += tokensPerToolCall + enc.Count(call.Function.Name) + enc.Count(call.Function.Arguments)
+		}
+		if m.ToolCallID != "" {
+			n += enc.Count(m.ToolCallID)
+		}
+	}
+	tools, err := countToolTokens(enc, body.Tools)
+	if err != nil {
+		return 0, err
+	}
+	return n + tools, nil
+}
+
+// schema is the part of the JSON Schema of function parameters the API
+// renders into the prompt.
+type schema struct {
+	Properties map[string]struct {
+		Type        string `json:"type"`
+		Description string `json:"description"`
+		Enum        []any  `json:"enum"`
+	} `json:"properties"`
+}
+
+func countToolTokens(enc tokenCounter, tools []Tool) (int, error) {
+	if len(tools) == 0 {
+		return 0, nil
+	}
+	n := toolsBase
+	for _, t := range tools {
+		f := t.Function
+		if f == nil {
+			continue
+		}
+		n += toolFunction + enc.Count(f.Name+":"+strings.TrimSuffix(f.Description, "."))
+		var s schema
+		if f.Parameters != nil {
+			data, err := json.Marshal(f.Parameters)
+			if err != nil {
+				return 0, fmt.Errorf("tools: %s: %w", f.Name, err)
+			}
+			if err = json.Unmarshal(data, &s); err != nil {
+				return 0, fmt.Errorf("tools: %s: %w", f.Name, err)
+			}
+		}
+		if len(s.Properties) == 0 {
+			continue
+		}
+		n += toolProperties
+		for key, p := range s.Properties {
+			n += toolProperty
+			if len(p.Enum) > 0 {
+				n += toolEnum
+				for _, item := range p.Enum {
+					n += toolEnumItem + enc.Count(fmt.Sprint(item))
+				}
+			}
+			n += enc.Count(key + ":" + p.Type + ":" + strings.TrimSuffix(p.Description, "."))
+		}
+	}
+	return n, nil
+}
+
+// imageURLTokens returns the tokens of an image part.
+func imageURLTokens(u *ChatMessageImageURL) (int, error) {
+	if u.Detail == ImageDetailLow {
+		return imageLowDetail, nil
+	}
+	data, ok := strings.CutPrefix(u.URL, "data:")
+	if !ok {
+		// The largest high detail image fills 2048x768 once scaled.
+		return ImageTokens(2048, 768, ImageDetailHigh), nil
+	}
+	_, encoded, ok := strings.Cut(data, ";base64,")
+	if !ok {
+		return 0, fmt.Errorf("image_url: unsupported data URL")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, fmt.Errorf("image_url: %w", err)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return 0, fmt.Errorf("image_url: %w", err)
+	}
+	return ImageTokens(config.Width, config.Height, u.Detail), nil
+}
+
+// ImageTokens returns the prompt tokens of an image of a size. High detail
+// images are scaled to fit in 2048x2048, then to 768 pixels on their short
+// side, and cost a base plus a fixed amount per 512 pixels square tile.
+func ImageTokens(width, height int, detail string) int {
+	if detail == ImageDetailLow || width <= 0 || height <= 0 {
+		return imageLowDetail
+	}
+	w, h := float64(width), float64(height)
+	if scale := 2048 / math.Max(w, h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+	if scale := 768 / math.Min(w, h); scale < 1 {
+		w, h = w*scale, h*scale
+	}
+	tiles := int(math.Ceil(w/512) * math.Ceil(h/512))
+	return imageLowDetail + imageTileTokens*tiles
+}
+
+// RemainingTokens returns the tokens the context window of the model leaves
+// for the completion of body, bounded by the output limit of the model. It
+// returns ErrContextWindowExceeded when the prompt fills the window.
+func (c *Client) RemainingTokens(body ChatRequestBody) (int, error) {
+	prompt, err := CountPromptTokens(body)
+	if err != nil {
+		return 0, err
+	}
+	return c.remainingTokens(body.Model, prompt)
+}
+
+func (c *Client) remainingTokens(model string, prompt int) (int, error) {
+	info, ok := c.models().Lookup(model)
+	if !ok || info.ContextWindow == 0 {
+		return 0, fmt.Errorf("%w: context window of %s unknown", ErrInvalidModel, model)
+	}
+	remaining := info.ContextWindow - prompt
+	if remaining <= 0 {
+		return 0, fmt.Errorf("%w: %d prompt tokens, %d in the window of %s",
+			ErrContextWindowExceeded, prompt, info.ContextWindow, model)
+	}
+	if info.MaxOutputTokens > 0 {
+		remaining = min(remaining, info.MaxOutputTokens)
+	}
+	return remaining, nil
+}
+
+// FitMaxTokens sets body.MaxTokens to the tokens RemainingTokens leaves for
+// the completion, so that the request does not fail for exceeding the
+// context window.
+func (c *Client) FitMaxTokens(body *ChatRequestBody) error {
+	remaining, err := c.RemainingTokens(*body)
+	if err != nil {
+		return err
+	}
+	body.MaxTokens = remaining
+	return nil
+}
@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/im15/openai-api-go/tokenizer"
+	_ "github.com/im15/openai-api-go/tokenizer/ranks"
+)
+
+// byteEncoding returns an encoding with a token per byte, so that texts
+// count as many tokens as they have bytes.
+func byteEncoding(t *testing.T) *tokenizer.Encoding {
+	t.Helper()
+	var ranks strings.Builder
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&ranks, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	enc, err := tokenizer.NewEncoding(tokenizer.CL100KBase, strings.NewReader(ranks.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func pngDataURL(t *testing.T, width, height int) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+func TestCountPromptTokens(t *testing.T) {
+	enc := byteEncoding(t)
+	messages := []*ChatMessage{
+		{Role: RoleSystem, Content: "abc"},
+		{Role: RoleUser, Name: "bob", Content: "hi"},
+	}
+	tests := []struct {
+		name string
+		body ChatRequestBody
+		want int
+	}{
+		{"messages", ChatRequestBody{Model: GPT4, Messages: messages},
+			3 + (3 + 6 + 3) + (3 + 4 + 2 + 1 + 3)},
+		{"0301 format", ChatRequestBody{Model: GPT35Turbo0301, Messages: messages},
+			3 + (4 + 6 + 3) + (4 + 4 + 2 - 1 + 3)},
+		{"tools", ChatRequestBody{Model: GPT4o, Tools: []Tool{{
+			Type: ToolTypeFunction,
+			Function: &FunctionDefinition{
+				Name:        "f",
+				Description: "Do.",
+				Parameters: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"a": map[string]any{"type": "string", "description": "x.", "enum": []string{"b", "cc"}},
+					},
+				},
+			},
+		}}}, 3 + 12 + 7 + len("f:Do") + 3 + 3 - 3 + (3 + 1) + (3 + 2) + len("a:string:x")},
+		{"tool call", ChatRequestBody{Model: GPT4o, Messages: []*ChatMessage{
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "c1", Type: ToolTypeFunction,
+				Function: FunctionCall{Name: "f", Arguments: `{}`}}}},
+			{Role: RoleTool, ToolCallID: "c1", Content: "ok"},
+		}}, 3 + (3 + 9 + 3 + 1 + 2) + (3 + 4 + 2 + 2)},
+		{"images", ChatRequestBody{Model: GPT4o, Messages: []*ChatMessage{{Role: RoleUser, MultiContent: []ChatMessagePart{
+			{Type: ChatMessagePartTypeText, Text: "what?"},
+			{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: "https://example.com/a.png", Detail: ImageDetailLow}},
+			{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: pngDataURL(t, 1024, 1024)}},
+			{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: "https://example.com/b.png"}},
+		}}}}, 3 + 3 + 4 + 5 + 85 + 765 + 1445},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := countPromptTokens(enc, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("countPromptTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	_, err := countPromptTokens(enc, ChatRequestBody{Messages: []*ChatMessage{{Role: RoleUser, MultiContent: []ChatMessagePart{
+		{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: "data:image/png;base64,AAAA"}},
+	}}}})
+	if err == nil {
+		t.Error("invalid image accepted")
+	}
+}
+
+func TestImageTokens(t *testing.T) {
+	tests := []struct {
+		width, height int
+		detail        string
+		want          int
+	}{
+		{1024, 1024, ImageDetailLow, 85},
+		{1024, 1024, ImageDetailHigh, 765},
+		{2048, 4096, ImageDetailHigh, 1105},
+		{512, 512, ImageDetailAuto, 255},
+	}
+	for _, tt := range tests {
+		if got := ImageTokens(tt.width, tt.height, tt.detail); got != tt.want {
+			t.Errorf("ImageTokens(%d, %d, %q) = %d, want %d", tt.width, tt.height, tt.detail, got, tt.want)
+		}
+	}
+}
+
+// promptTokensMessages is the prompt of the OpenAI cookbook's "How to count
+// tokens with tiktoken".
+var promptTokensMessages = []*ChatMessage{
+	{Role: RoleSystem, Content: "You are a helpful, pattern-following assistant that translates corporate jargon into plain English."},
+	{Role: RoleSystem, Name: "example_user", Content: "New synergies will help drive top-line growth."},
+	{Role: RoleSystem, Name: "example_assistant", Content: "Things working well together will increase revenue."},
+	{Role: RoleSystem, Name: "example_user", Content: "Let's circle back when we have more bandwidth to touch base on opportunities for increased leverage."},
+	{Role: RoleSystem, Name: "example_assistant", Content: "Let's talk later when we're less busy about how to do better."},
+	{Role: RoleUser, Content: "This late pivot means we don't have time to boil the ocean for the client deliverable."},
+}
+
+// TestCountPromptTokens_cookbook compares predictions with the prompt tokens
+// the API reported in the cookbook.
+func TestCountPromptTokens_cookbook(t *testing.T) {
+	tests := map[string]int{
+		GPT35Turbo0301: 127,
+		GPT35Turbo0613: 129,
+		"gpt-4-0613":   129,
+		GPT4o:          124,
+	}
+	for model, want := range tests {
+		got, err := CountPromptTokens(ChatRequestBody{Model: model, Messages: promptTokensMessages})
+		if err != nil {
+			t.Errorf("%s: %v", model, err)
+		} else if got != want {
+			t.Errorf("%s: CountPromptTokens() = %d, API reported %d", model, got, want)
+		}
+	}
+}
+
+func TestClient_remainingTokens(t *testing.T) {
+	c := &Client{}
+	tests := []struct {
+		model   string
+		prompt  int
+		want    int
+		wantErr error
+	}{
+		{GPT4, 8000, 192, nil},
+		{GPT4o, 1000, 16384, nil},
+		{GPT4, 8192, 0, ErrContextWindowExceeded},
+		{"llama-3", 10, 0, ErrInvalidModel},
+	}
+	for _, tt := range tests {
+		got, err := c.remainingTokens(tt.model, tt.prompt)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("remainingTokens(%s, %d) = %d, %v", tt.model, tt.prompt, got, err)
+		}
+	}
+}
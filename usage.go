@@ -0,0 +1,344 @@
+package openai
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Labels attribute the usage of the API to who or what caused it.
+type Labels struct {
+	Tenant  string `json:"tenant,omitempty"`
+	Feature string `json:"feature,omitempty"`
+	User    string `json:"user,omitempty"`
+}
+
+type labelsKey struct{}
+
+// WithLabels returns a context carrying labels for the calls made with it.
+// The non-empty fields replace those of labels the context already carries.
+func WithLabels(ctx context.Context, labels Labels) context.Context {
+	l := LabelsFrom(ctx)
+	if labels.Tenant != "" {
+		l.Tenant = labels.Tenant
+	}
+	if labels.Feature != "" {
+		l.Feature = labels.Feature
+	}
+	if labels.User != "" {
+		l.User = labels.User
+	}
+	return context.WithValue(ctx, labelsKey{}, l)
+}
+
+// LabelsFrom returns the labels carried by a context.
+func LabelsFrom(ctx context.Context) Labels {
+	l, _ := ctx.Value(labelsKey{}).(Labels)
+	return l
+}
+
+// UsageRecord is the usage of a single call.
+type UsageRecord struct {
+	Time time.Time `json:"time"`
+	Labels
+	Operation        string `json:"operation"`
+	Model            string `json:"model,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+	Images           int    `json:"images,omitempty"`
+	ImageSize        string `json:"image_size,omitempty"`
+	// AudioSeconds is the duration of transcribed or translated audio, known
+	// for the verbose_json response format only.
+	AudioSeconds float64 `json:"audio_seconds,omitempty"`
+	// Cost is in US dollars, zero when the price table does not price the
+	// usage.
+	Cost float64 `json:"cost"`
+	// Estimated reports that the tokens were counted by the client, for
+	// streams whose usage the API did not report.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// DefaultImagePrices are the prices in US dollars of an image by size.
+var DefaultImagePrices = map[string]float64{
+	Size256:  0.016,
+	Size512:  0.018,
+	Size1024: 0.020,
+}
+
+// PriceTable prices usage records.
+type PriceTable struct {
+	// Models provides the prices of tokens and audio, DefaultModels when nil.
+	Models *ModelRegistry
+	// Overrides replace the registry prices of models, e.g. negotiated ones.
+	Overrides map[string]Pricing
+	// Images are the prices of an image by size, DefaultImagePrices when nil.
+	Images map[string]float64
+}
+
+// Pricing returns the price of a model, reporting false when it is unknown.
+func (p *PriceTable) Pricing(model string) (Pricing, bool) {
+	if pricing, ok := p.Overrides[model]; ok {
+		return pricing, true
+	}
+	models := p.Models
+	if models == nil {
+		models = DefaultModels
+	}
+	info, ok := models.Lookup(model)
+	return info.Pricing, ok && info.Pricing != Pricing{}
+}
+
+// Cost returns the cost of a record in US dollars.
+func (p *PriceTable) Cost(r UsageRecord) float64 {
+	cost := 0.0
+	if r.Images > 0 {
+		images := p.Images
+		if images == nil {
+			images = DefaultImagePrices
+		}
+		size := r.ImageSize
+		if size == "" {
+			size = Size1024
+		}
+		cost += float64(r.Images) * images[size]
+	}
+	if pricing, ok := p.Pricing(r.Model); ok {
+		cost += float64(r.PromptTokens)*pricing.Input/1e6 +
+			float64(r.CompletionTokens)*pricing.Output/1e6 +
+			r.AudioSeconds/60*pricing.PerMinute
+	}
+	return cost
+}
+
+// UsageTotal sums the usage of the calls of a group.
+type UsageTotal struct {
+	Labels
+	Operation        string  `json:"operation,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Images           int     `json:"images"`
+	AudioSeconds     float64 `json:"audio_seconds"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *UsageTotal) add(r UsageRecord) {
+	t.Requests++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.Images += r.Images
+	t.AudioSeconds += r.AudioSeconds
+	t.Cost += r.Cost
+}
+
+// UsageSnapshot is the usage recorded by a UsageTracker.
+type UsageSnapshot struct {
+	// Since is when the tracker was created or last reset.
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	// Totals are grouped by labels, operation and model.
+	Totals []UsageTotal `json:"totals"`
+	// Total sums every group.
+	Total UsageTotal `json:"total"`
+}
+
+var usageCSVHeader = []string{
+	"tenant", "feature", "user", "operation", "model", "requests",
+	"prompt_tokens", "completion_tokens", "images", "audio_seconds", "cost_usd",
+}
+
+// WriteCSV writes the totals of the snapshot as CSV, with a header.
+func (s UsageSnapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write(usageCSVHeader)
+	for _, t := range s.Totals {
+		_ = cw.Write([]string{
+			t.Tenant, t.Feature, t.User, t.Operation, t.Model,
+			strconv.Itoa(t.Requests),
+			strconv.Itoa(t.PromptTokens),
+			strconv.Itoa(t.CompletionTokens),
+			strconv.Itoa(t.Images),
+			strconv.FormatFloat(t.AudioSeconds, 'f', -1, 64),
+			strconv.FormatFloat(t.Cost, 'f', 6, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// UsageTrackerOptions configures a UsageTracker.
+type UsageTrackerOptions struct {
+	Prices PriceTable
+	// OnRecord is called with every record, e.g. to store it. It must not
+	// block.
+	OnRecord func(UsageRecord)
+}
+
+// UsageTracker records the tokens, images and audio of the calls of a
+// Client and prices them. Calls served from the cache are not recorded.
+type UsageTracker struct {
+	opts UsageTrackerOptions
+	now  func() time.Time
+
+	mu     sync.Mutex
+	since  time.Time
+	totals map[usageKey]*UsageTotal
+}
+
+type usageKey struct {
+	Labels
+	operation, model string
+}
+
+// NewUsageTracker returns an empty UsageTracker.
+func NewUsageTracker(opts UsageTrackerOptions) *UsageTracker {
+	t := &UsageTracker{opts: opts, now: time.Now}
+	t.Reset()
+	return t
+}
+
+// Reset forgets the recorded usage.
+func (t *UsageTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.since = t.now()
+	t.totals = make(map[usageKey]*UsageTotal)
+}
+
+// Record prices and records the usage of a call made outside the Client,
+// e.g. by another process.
+func (t *UsageTracker) Record(r UsageRecord) {
+	if r.Time.IsZero() {
+		r.Time = t.now()
+	}
+	r.Cost = t.opts.Prices.Cost(r)
+	t.mu.Lock()
+	key := usageKey{r.Labels, r.Operation, r.Model}
+	total, ok := t.totals[key]
+	if !ok {
+		total = &UsageTotal{Labels: r.Labels, Operation: r.Operation, Model: r.Model}
+		t.totals[key] = total
+	}
+	total.add(r)
+	t.mu.Unlock()
+	if t.opts.OnRecord != nil {
+		t.opts.OnRecord(r)
+	}
+}
+
+// Snapshot returns the usage recorded so far, sorted by labels, operation
+// and model.
+func (t *UsageTracker) Snapshot() UsageSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := UsageSnapshot{Since: t.since, Until: t.now(), Totals: make([]UsageTotal, 0, len(t.totals))}
+	for _, total := range t.totals {
+		s.Totals = append(s.Totals, *total)
+		s.Total.Requests += total.Requests
+		s.Total.PromptTokens += total.PromptTokens
+		s.Total.CompletionTokens += total.CompletionTokens
+		s.Total.Images += total.Images
+		s.Total.AudioSeconds += total.AudioSeconds
+		s.Total.Cost += total.Cost
+	}
+	sort.Slice(s.Totals, func(i, j int) bool {
+		a, b := s.Totals[i], s.Totals[j]
+		return strings.Join([]string{a.Tenant, a.Feature, a.User, a.Operation, a.Model}, "\x00") <
+			strings.Join([]string{b.Tenant, b.Feature, b.User, b.Operation, b.Model}, "\x00")
+	})
+	return s
+}
+
+// Middleware returns the Middleware recording the usage of a Client's calls.
+func (t *UsageTracker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(call *Call) error {
+			err := next(call)
+			if err == nil {
+				observeUsage(call, t.Record)
+			}
+			return err
+		}
+	}
+}
+
+// observeUsage calls record with the usage of a successful call, once its
+// stream is closed for streams. It reports false when record will not be
+// called, for calls served from the cache.
+func observeUsage(call *Call, record func(UsageRecord)) bool {
+	if meta := responseMetaOf(call.Result); meta != nil && meta.CacheHit {
+		return false
+	}
+	r := UsageRecord{
+		Labels:    LabelsFrom(call.Context()),
+		Operation: call.Operation,
+		Model:     call.Model(),
+	}
+	if call.Stream() {
+		return observeStream(call, r, record)
+	}
+	if usage := call.Usage(); usage != nil {
+		r.PromptTokens, r.CompletionTokens = usage.PromptTokens, usage.CompletionTokens
+	}
+	switch res := call.Result.(type) {
+	case *ImageResponseBody:
+		r.Images, r.ImageSize = len(res.Data), imageSizeOf(call.Body)
+	case *AudioResponseBody:
+		r.AudioSeconds = res.Duration
+	}
+	record(r)
+	return true
+}
+
+// observeStream records the usage of a stream once it is closed, from its
+// last chunk when the API reports it, or else by counting its tokens.
+func observeStream(call *Call, r UsageRecord, record func(UsageRecord)) bool {
+	var (
+		usage      *TokensUsage
+		completion strings.Builder
+	)
+	return call.InterceptStream(func(chunk *ChatStreamChunk) {
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil {
+				completion.WriteString(choice.Delta.Content)
+				for _, tc := range choice.Delta.ToolCalls {
+					completion.WriteString(tc.Function.Name)
+					completion.WriteString(tc.Function.Arguments)
+				}
+			}
+		}
+	}, func() {
+		if usage != nil {
+			r.PromptTokens, r.CompletionTokens = usage.PromptTokens, usage.CompletionTokens
+		} else {
+			// Models without a known encoding are counted a token per
+			// byte, as Budget estimates them.
+			counter := counterFor(r.Model)
+			r.Estimated = true
+			r.PromptTokens, _ = countPromptTokens(counter, call.Body.(ChatRequestBody))
+			r.CompletionTokens = counter.Count(completion.String())
+		}
+		record(r)
+	})
+}
+
+func imageSizeOf(body any) string {
+	switch b := body.(type) {
+	case ImageRequestBody:
+		return b.Size
+	case ImageEditRequestBody:
+		return b.Size
+	case ImageVariationRequestBody:
+		return b.Size
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func usageTestServer(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/chat/completions":
+		if strings.Contains(r.Header.Get("Accept"), "event-stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprint(w, `data: {"choices":[{"index":0,"delta":{"role":"assistant","content":"hi"}}]}`+"\n\n")
+			if strings.Contains(string(body), "include_usage") {
+				fmt.Fprint(w, `data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":1,"total_tokens":8}}`+"\n\n")
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}],
+			"usage":{"prompt_tokens":1000000,"completion_tokens":500000,"total_tokens":1500000}}`)
+	case "/v1/images/generations":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":[{"url":"a"},{"url":"b"}]}`)
+	case "/v1/audio/transcriptions":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"text":"hello","duration":90}`)
+	}
+}
+
+func TestUsageTracker(t *testing.T) {
+	c := newTestClient(t, usageTestServer)
+	recorded := make(chan UsageRecord, 10)
+	tracker := NewUsageTracker(UsageTrackerOptions{
+		Prices:   PriceTable{Overrides: map[string]Pricing{"custom": {Input: 1, Output: 2}}},
+		OnRecord: func(r UsageRecord) { recorded <- r },
+	})
+	c.Use(tracker.Middleware())
+	ctx := WithLabels(context.Background(), Labels{Tenant: "acme", Feature: "chat"})
+	messages := []*ChatMessage{{Role: RoleUser, Content: "hi"}}
+
+	if _, err := c.CreateChatCompletion(WithLabels(ctx, Labels{User: "u1"}),
+		ChatRequestBody{Model: GPT4o, Messages: messages}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateChatCompletion(ctx, ChatRequestBody{Model: "custom", Messages: messages}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.CreateChatCompletion(ctx, ChatRequestBody{Model: GPT4o, Messages: messages, Stream: true,
+		StreamOptions: &StreamOptions{IncludeUsage: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range res.StreamChan {
+	}
+	if _, err = c.CreateImage(ctx, ImageRequestBody{Prompt: "cat", N: 2, Size: Size512}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.CreateTranscription(context.Background(), AudioRequestBody{File: "testdata/otter.png", Model: Whisper1,
+		ResponseFormat: "verbose_json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream is recorded once it is closed, after the channel drained.
+	for i := 0; i < 5; i++ {
+		select {
+		case r := <-recorded:
+			if r.Time.IsZero() || r.Estimated {
+				t.Errorf("record = %+v", r)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("recorded %d calls, want 5", i)
+		}
+	}
+	s := tracker.Snapshot()
+	want := []UsageTotal{
+		{Operation: "CreateTranscription", Model: Whisper1, Requests: 1, AudioSeconds: 90, Cost: 0.009},
+		{Labels: Labels{Tenant: "acme", Feature: "chat"}, Operation: "CreateChatCompletion", Model: "custom",
+			Requests: 1, PromptTokens: 1000000, CompletionTokens: 500000, Cost: 2},
+		{Labels: Labels{Tenant: "acme", Feature: "chat"}, Operation: "CreateChatCompletion", Model: GPT4o,
+			Requests: 1, PromptTokens: 7, CompletionTokens: 1, Cost: 7*2.5/1e6 + 10/1e6},
+		{Labels: Labels{Tenant: "acme", Feature: "chat"}, Operation: "CreateImage", Requests: 1, Images: 2, Cost: 0.036},
+		{Labels: Labels{Tenant: "acme", Feature: "chat", User: "u1"}, Operation: "CreateChatCompletion", Model: GPT4o,
+			Requests: 1, PromptTokens: 1000000, CompletionTokens: 500000, Cost: 2.5 + 5},
+	}
+	if len(s.Totals) != len(want) {
+		t.Fatalf("Totals = %+v", s.Totals)
+	}
+	total := 0.0
+	for i, got := range s.Totals {
+		if math.Abs(got.Cost-want[i].Cost) > 1e-9 {
+			t.Errorf("Totals[%d].Cost = %g, want %g", i, got.Cost, want[i].Cost)
+		}
+		got.Cost = want[i].Cost
+		if got != want[i] {
+			t.Errorf("Totals[%d] = %+v, want %+v", i, got, want[i])
+		}
+		total += want[i].Cost
+	}
+	if s.Total.Requests != 5 || math.Abs(s.Total.Cost-total) > 1e-9 {
+		t.Errorf("Total = %+v", s.Total)
+	}
+
+	var csv strings.Builder
+	if err = s.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 6 || lines[0] != strings.Join(usageCSVHeader, ",") ||
+		lines[2] != "acme,chat,,CreateChatCompletion,custom,1,1000000,500000,0,0,2.000000" {
+		t.Errorf("CSV =\n%s", csv.String())
+	}
+
+	tracker.Reset()
+	if s := tracker.Snapshot(); len(s.Totals) != 0 || s.Total.Requests != 0 {
+		t.Errorf("Snapshot() after Reset = %+v", s)
+	}
+}
+
+func TestUsageTracker_estimatedStream(t *testing.T) {
+	c := newTestClient(t, usageTestServer)
+	recorded := make(chan UsageRecord, 1)
+	tracker := NewUsageTracker(UsageTrackerOptions{
+		Prices:   PriceTable{Overrides: map[string]Pricing{"custom": {Input: 1, Output: 2}}},
+		OnRecord: func(r UsageRecord) { recorded <- r },
+	})
+	c.Use(tracker.Middleware())
+	messages := []*ChatMessage{{Role: RoleUser, Content: "hi"}}
+
+	tests := []struct {
+		model              string
+		prompt, completion int
+	}{
+		{GPT4o, 8, 1},
+		// No tokenizer knows the model: a token per byte.
+		{"custom", 12, 2},
+	}
+	for _, tt := range tests {
+		res, err := c.CreateChatCompletion(context.Background(),
+			ChatRequestBody{Model: tt.model, Messages: messages, Stream: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range res.StreamChan {
+		}
+		select {
+		case r := <-recorded:
+			if !r.Estimated || r.PromptTokens != tt.prompt || r.CompletionTokens != tt.completion || r.Cost == 0 {
+				t.Errorf("%s: record = %+v", tt.model, r)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: stream not recorded", tt.model)
+		}
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	ctx := WithLabels(context.Background(), Labels{Tenant: "a", Feature: "f"})
+	ctx = WithLabels(ctx, Labels{Feature: "g", User: "u"})
+	if got := LabelsFrom(ctx); got != (Labels{Tenant: "a", Feature: "g", User: "u"}) {
+		t.Errorf("LabelsFrom() = %+v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidRequest is matched by the errors returned for request bodies
+// that fail validation.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Validator is implemented by request bodies that check their fields. The
+// Client validates bodies before sending them, unless SkipValidation is set
+// or WithoutValidation is passed.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError describes an invalid field of a request body.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "messages[1].role".
+	Field string
+	// Message says what is wrong with it.
+	Message string
+	// Err is a sentinel error the field error matches, such as
+	// ErrInvalidModel, or nil.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("`%s` %s", e.Field, e.Message)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned by Validate with every invalid field of a
+// request body.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Error()
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+// Is makes errors.Is(err, ErrInvalidRequest) report true.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// Unwrap returns the field errors, so that errors.Is matches their sentinel
+// errors.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+	return errs
+}
+
+// WithoutValidation sends the request body without validating it, e.g. to
+// use a value the checks do not know about yet.
+func WithoutValidation() RequestOption {
+	return func(o *requestOptions) {
+		o.skipValidation = true
+	}
+}
+
+// validation collects the field errors of a request body.
+type validation struct {
+	fields []*FieldError
+}
+
+func (v *validation) add(field, format string, args ...any) {
+	v.fields = append(v.fields, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validation) required(field, value string) {
+	if value == "" {
+		v.add(field, "not provided")
+	}
+}
+
+// oneOf checks an optional value against its allowed values.
+func (v *validation) oneOf(field, value string, allowed ...string) {
+	if value != "" && !contains(allowed, value) {
+		v.add(field, "must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func (v *validation) floatRange(field string, value, min, max float32) {
+	if value < min || value > max {
+		v.add(field, "must be between %g and %g", min, max)
+	}
+}
+
+// intRange checks an optional value, zero meaning the API default.
+func (v *validation) intRange(field string, value, min, max int) {
+	if value != 0 && (value < min || value > max) {
+		v.add(field, "must be between %d and %d", min, max)
+	}
+}
+
+func (v *validation) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, "must be at most %d characters", max)
+	}
+}
+
+func (v *validation) logitBias(field string, bias map[string]int) {
+	for token, value := range bias {
+		if value < -100 || value > 100 {
+			v.add(fmt.Sprintf("%s.%s", field, token), "must be between -100 and 100")
+		}
+	}
+}
+
+func (v *validation) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+func contains(values []string, value string) bool {
+	for _, s := range values {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
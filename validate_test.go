@@ -0,0 +1,110 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fieldsOf(err error) []string {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   Validator
+		fields []string
+	}{
+		{"chat valid", ChatRequestBody{Model: GPT35Turbo, Messages: []*ChatMessage{{Role: RoleUser, Content: "hi"}}}, nil},
+		{"chat invalid", ChatRequestBody{
+			Model:       GPT35Turbo,
+			Messages:    []*ChatMessage{{Role: "robot"}, nil},
+			Temperature: 2.5,
+			TopP:        -1,
+			N:           200,
+			LogitBias:   map[string]int{"50256": -101},
+		}, []string{"messages[0].role", "messages[1]", "temperature", "top_p", "n", "logit_bias.50256"}},
+		{"chat no messages", ChatRequestBody{Model: GPT4}, []string{"messages"}},
+		{"chat tools", ChatRequestBody{
+			Model: GPT4o,
+			Messages: []*ChatMessage{
+				{Role: RoleTool, Content: "ok"},
+				{Role: RoleUser, MultiContent: []ChatMessagePart{{Type: ChatMessagePartTypeImageURL}}},
+			},
+			Tools: []Tool{{Type: ToolTypeFunction}},
+		}, []string{"messages[0].tool_call_id", "messages[1].content[0].image_url", "tools[0].function.name"}},
+		{"chat logprobs", ChatRequestBody{
+			Model:       GPT4o,
+			Messages:    []*ChatMessage{{Role: RoleUser, Content: "hi"}},
+			TopLogprobs: 21,
+		}, []string{"top_logprobs", "top_logprobs"}},
+		{"completion exclusive options", CompletionRequestBody{
+			Model:  TextDavinci003,
+			N:      3,
+			BestOf: 2,
+			Stream: true,
+			Echo:   true,
+			Suffix: "end",
+		}, []string{"best_of", "best_of", "echo"}},
+		{"edit", EditRequestBody{Model: CodeDavinciEdit001}, []string{"instruction"}},
+		{"embeddings", EmbeddingsRequestBody{}, []string{"model", "input"}},
+		{"image", ImageRequestBody{Prompt: strings.Repeat("a", 1001), N: 11, Size: "10x10"}, []string{"prompt", "n", "size"}},
+		{"image edit", ImageEditRequestBody{ResponseFormat: "png"}, []string{"image", "prompt", "response_format"}},
+		{"image variation", ImageVariationRequestBody{Image: "otter.png", N: 2}, nil},
+		{"audio", AudioRequestBody{File: "a.mp3", Model: Whisper1, Temperature: 1.5}, []string{"temperature"}},
+		{"moderation", ModerationRequestBody{Model: OmniModerationLatest}, []string{"input"}},
+		{"upload file", UploadFileRequestBody{File: "data.jsonl"}, []string{"purpose"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.body.Validate()
+			if got := fieldsOf(err); !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("invalid fields = %v, want %v (%v)", got, tt.fields, err)
+			}
+			if (err != nil) != errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("errors.Is(%v, ErrInvalidRequest) mismatch", err)
+			}
+		})
+	}
+}
+
+func TestClient_validation(t *testing.T) {
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"chat.completion"}`))
+	})
+	body := ChatRequestBody{Model: TextEmbeddingAda002, Messages: []*ChatMessage{{Role: RoleUser, Content: "hi"}}}
+
+	_, err := c.CreateChatCompletion(context.Background(), body)
+	if !errors.Is(err, ErrInvalidModel) {
+		t.Errorf("error = %v, want ErrInvalidModel", err)
+	}
+	if requests != 0 {
+		t.Errorf("invalid request was sent")
+	}
+
+	if _, err = c.CreateChatCompletion(context.Background(), body, WithoutValidation()); err != nil {
+		t.Errorf("error without validation = %v", err)
+	}
+	c.SkipValidation = true
+	if _, err = c.CreateChatCompletion(context.Background(), body); err != nil {
+		t.Errorf("error with SkipValidation = %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("sent %d requests, want 2", requests)
+	}
+}